@@ -0,0 +1,28 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package all registers every format that package bank can read.  A
+// program imports it for its side effects:
+//
+//	import _ "github.com/evmar/fin/bank/all"
+package all
+
+import (
+	_ "github.com/evmar/fin/bank/camt"
+	_ "github.com/evmar/fin/bank/csv"
+	_ "github.com/evmar/fin/bank/journal"
+	_ "github.com/evmar/fin/bank/mt940"
+	_ "github.com/evmar/fin/bank/qfx"
+	_ "github.com/evmar/fin/bank/qif"
+)
@@ -0,0 +1,102 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bank holds what the readers of bank statement formats share:
+// the entries they read, and a registry of the formats, so that a file
+// can be read without knowing which package reads it.
+package bank
+
+import "time"
+
+// ClearedType represents the "cleared" state of a transaction.
+type ClearedType int
+
+const (
+	NotCleared ClearedType = iota
+	Cleared
+	Reconciled
+)
+
+// Entry represents a single entry in the ledger.
+type Entry struct {
+	// Number is a string identifier for the transaction.
+	// Sample value: "0224169143028400738398".
+	Number string
+
+	// Date is the date of the transaction.
+	Date time.Time
+
+	// ValueDate is the date the money becomes available, if the input
+	// reports it separately from Date, or zero otherwise.
+	ValueDate time.Time
+
+	// Amount is the amount of money, in cents.
+	// Amount will be negative for withdrawals.
+	Amount int
+
+	// Currency is the ISO 4217 code of the currency of Amount, or empty
+	// if the input doesn't say.  Sample value: "USD".
+	Currency string
+
+	// Payee is the recipient of the transaction as reported by the bank.
+	// Sample value: "WHOLEFDS NOE 10379 SAN FRANCISCOCA".
+	Payee string
+
+	// From and To are the sender and recipient of the transaction as
+	// the input names them, for inputs such as Venmo that report both
+	// parties.  Payee is whichever of them is the counterparty.
+	// Sample values: "Jane Doe", "John Smith".
+	From, To string
+
+	// Address is the address of the recipient as reported by the bank.
+	// Sample value: "SAN FRANCISCCA".
+	Address string
+
+	// Cleared is the status of the transaction, or zero if not present.
+	Cleared ClearedType
+
+	// Memo is a free-form note attached to the transaction.
+	Memo string
+
+	// Category is the category assigned to the transaction, if any.
+	// Subcategories are separated by colons, and a class may follow
+	// after a slash.  Sample value: "Food:Restaurants".
+	Category string
+
+	// Type is the kind of transaction as reported by the bank, if known.
+	// Sample value: "DEBIT".
+	Type string
+
+	// Account is the name of the account the transaction belongs to,
+	// for inputs that hold several accounts, e.g. from a QIF "!Account"
+	// header or a statement's IBAN.
+	Account string
+
+	// Splits divides the transaction among categories, if it is a
+	// split transaction.  The split amounts normally sum to Amount.
+	Splits []Split
+}
+
+// Split is one part of a split transaction.
+type Split struct {
+	// Category is the category of this part.
+	// Sample value: "Taxes:Federal".
+	Category string
+
+	// Memo is a free-form note attached to this part.
+	Memo string
+
+	// Amount is the amount of money in this part, in cents.
+	Amount int
+}
@@ -0,0 +1,433 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package camt parses ISO 20022 bank-to-customer statements: camt.053
+// end-of-day statements and camt.052 intraday reports, as exported by
+// many European banks.
+package camt
+
+// The schemas are at https://www.iso20022.org.  Elements are matched
+// by local name only, so any version of the camt.052/053 namespaces is
+// accepted; the field layouts read here are common to all of them.
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/money"
+	"github.com/evmar/fin/bank/qif"
+)
+
+// Statement describes the statement (camt.053) or report (camt.052)
+// that the most recently read entries came from.
+type Statement struct {
+	// Kind is "camt.053" for a statement or "camt.052" for a report.
+	Kind string
+
+	// ID is the bank's identifier for the statement.
+	ID string
+
+	// Account is the IBAN of the account, or its other identifier if
+	// it has no IBAN.  Sample value: "DE89370400440532013000".
+	Account string
+
+	// Currency is the currency of the account, if given.
+	Currency string
+
+	// Opening and Closing are the booked balances, in cents, at the
+	// start and end of the statement, as of OpeningDate and
+	// ClosingDate.  They are zero if not reported.
+	Opening     int
+	OpeningDate time.Time
+	Closing     int
+	ClosingDate time.Time
+}
+
+// amount is an amount element, e.g. <Amt Ccy="EUR">12.34</Amt>.
+type amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// dateTime is a date element, which holds either a date or a datetime.
+type dateTime struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type account struct {
+	IBAN     string `xml:"Id>IBAN"`
+	Other    string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type balance struct {
+	// Code is e.g. "OPBD" (opening booked) or "CLBD" (closing booked).
+	Code        string   `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      amount   `xml:"Amt"`
+	CreditDebit string   `xml:"CdtDbtInd"`
+	Date        dateTime `xml:"Dt"`
+}
+
+// party is a debtor or creditor.  Later schema versions wrap the name
+// in a Pty element.
+type party struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+func (p *party) name() string {
+	if p == nil {
+		return ""
+	}
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.PartyName
+}
+
+type txDetails struct {
+	EndToEndID   string   `xml:"Refs>EndToEndId"`
+	AcctSvcrRef  string   `xml:"Refs>AcctSvcrRef"`
+	Amount       *amount  `xml:"Amt"`
+	CreditDebit  string   `xml:"CdtDbtInd"`
+	Debtor       *party   `xml:"RltdPties>Dbtr"`
+	Creditor     *party   `xml:"RltdPties>Cdtr"`
+	UltDebtor    *party   `xml:"RltdPties>UltmtDbtr"`
+	UltCreditor  *party   `xml:"RltdPties>UltmtCdtr"`
+	Unstructured []string `xml:"RmtInf>Ustrd"`
+	CreditorRef  string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AddtlInfo    string   `xml:"AddtlTxInf"`
+}
+
+// status is an entry status, e.g. "BOOK".  It is a bare code in older
+// schema versions and wrapped in a Cd element in later ones.
+type status struct {
+	Code string `xml:"Cd"`
+	Text string `xml:",chardata"`
+}
+
+type ntry struct {
+	Ref         string      `xml:"NtryRef"`
+	Amount      amount      `xml:"Amt"`
+	CreditDebit string      `xml:"CdtDbtInd"`
+	Status      status      `xml:"Sts"`
+	BookingDate dateTime    `xml:"BookgDt"`
+	ValueDate   dateTime    `xml:"ValDt"`
+	AcctSvcrRef string      `xml:"AcctSvcrRef"`
+	Domain      string      `xml:"BkTxCd>Domn>Cd"`
+	Family      string      `xml:"BkTxCd>Domn>Fmly>Cd"`
+	SubFamily   string      `xml:"BkTxCd>Domn>Fmly>SubFmlyCd"`
+	Proprietary string      `xml:"BkTxCd>Prtry>Cd"`
+	Details     []txDetails `xml:"NtryDtls>TxDtls"`
+	AddtlInfo   string      `xml:"AddtlNtryInf"`
+}
+
+type Reader struct {
+	d *xml.Decoder
+
+	stmt *Statement
+	// queued holds entries split out of a batch booking.
+	queued []*qif.Entry
+}
+
+func NewReader(r io.Reader) *Reader {
+	d := xml.NewDecoder(charset.NewReader(r, ""))
+	// The input is already decoded to UTF-8, whatever the declaration
+	// says.
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return &Reader{d: d}
+}
+
+// Statement returns the statement containing the most recently read
+// entry, or nil if no statement has been seen yet.
+func (r *Reader) Statement() *Statement {
+	return r.stmt
+}
+
+func (r *Reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", r.d.InputOffset(), fmt.Sprintf(format, args...))
+}
+
+// parseDate parses a date element, keeping only the date part of a
+// datetime.
+func parseDate(d dateTime) (time.Time, error) {
+	s := d.Date
+	if s == "" {
+		s = d.DateTime
+	}
+	s = strings.TrimSpace(s)
+	if len(s) < 10 {
+		return time.Time{}, fmt.Errorf("bad date %q", s)
+	}
+	return time.Parse("2006-01-02", s[:10])
+}
+
+// parseAmount parses an amount with its credit/debit indicator, which
+// is "CRDT" for money in and "DBIT" for money out.
+func parseAmount(a amount, creditDebit string) (int, error) {
+	n, err := money.ParseDecimal(a.Value)
+	if err != nil {
+		return 0, err
+	}
+	switch creditDebit {
+	case "CRDT":
+	case "DBIT":
+		n = -n
+	default:
+		return 0, fmt.Errorf("bad credit/debit indicator %q", creditDebit)
+	}
+	return n, nil
+}
+
+func (r *Reader) balance(b *balance) error {
+	n, err := parseAmount(b.Amount, b.CreditDebit)
+	if err != nil {
+		return r.errorf("Bal: %v", err)
+	}
+	date, err := parseDate(b.Date)
+	if err != nil {
+		return r.errorf("Bal: %v", err)
+	}
+	switch b.Code {
+	case "OPBD", "PRCD":
+		// PRCD, the previous statement's closing balance, stands in
+		// for the opening balance in some exports.
+		if b.Code == "OPBD" || r.stmt.OpeningDate.IsZero() {
+			r.stmt.Opening, r.stmt.OpeningDate = n, date
+		}
+	case "CLBD":
+		r.stmt.Closing, r.stmt.ClosingDate = n, date
+	}
+	return nil
+}
+
+// counterparty returns the other party of a transaction: the creditor
+// of a payment out, or the debtor of a payment in.
+func counterparty(tx *txDetails, creditDebit string) string {
+	if tx == nil {
+		return ""
+	}
+	var name string
+	if creditDebit == "DBIT" {
+		name = tx.Creditor.name()
+		if name == "" {
+			name = tx.UltCreditor.name()
+		}
+	} else {
+		name = tx.Debtor.name()
+		if name == "" {
+			name = tx.UltDebtor.name()
+		}
+	}
+	return name
+}
+
+// entry converts an Ntry element, using the transaction details tx if
+// present, into an Entry.
+func (r *Reader) entry(n *ntry, tx *txDetails) (*qif.Entry, error) {
+	e := &qif.Entry{Cleared: qif.Cleared, Account: r.stmt.Account}
+	var err error
+	e.Date, err = parseDate(n.BookingDate)
+	if err != nil {
+		return nil, r.errorf("Ntry BookgDt: %v", err)
+	}
+	if n.ValueDate != (dateTime{}) {
+		e.ValueDate, err = parseDate(n.ValueDate)
+		if err != nil {
+			return nil, r.errorf("Ntry ValDt: %v", err)
+		}
+	}
+
+	amt, creditDebit := n.Amount, n.CreditDebit
+	if tx != nil && tx.Amount != nil && tx.CreditDebit != "" {
+		amt, creditDebit = *tx.Amount, tx.CreditDebit
+	}
+	e.Amount, err = parseAmount(amt, creditDebit)
+	if err != nil {
+		return nil, r.errorf("Ntry Amt: %v", err)
+	}
+	e.Currency = amt.Currency
+
+	e.Payee = counterparty(tx, creditDebit)
+	if tx != nil {
+		e.Memo = strings.Join(tx.Unstructured, " ")
+		if e.Memo == "" {
+			e.Memo = tx.CreditorRef
+		}
+		if e.Memo == "" {
+			e.Memo = tx.AddtlInfo
+		}
+	}
+	if e.Payee == "" {
+		e.Payee = n.AddtlInfo
+	} else if e.Memo == "" {
+		e.Memo = n.AddtlInfo
+	}
+	e.Payee = strings.Join(strings.Fields(e.Payee), " ")
+	e.Memo = strings.Join(strings.Fields(e.Memo), " ")
+
+	// The bank's own reference is unique within the account, so it is
+	// preferred.  An entry split into several transactions shares its
+	// reference between them, so each then falls back to the end-to-end
+	// ID set by the payer.  "NOTPROVIDED" is the placeholder for none.
+	var refs []string
+	if tx != nil {
+		refs = append(refs, tx.AcctSvcrRef)
+	}
+	if len(n.Details) <= 1 {
+		refs = append(refs, n.AcctSvcrRef)
+	}
+	if tx != nil {
+		refs = append(refs, tx.EndToEndID)
+	}
+	refs = append(refs, n.Ref)
+	for _, ref := range refs {
+		if ref = strings.TrimSpace(ref); ref != "" && ref != "NOTPROVIDED" {
+			e.Number = ref
+			break
+		}
+	}
+
+	if n.Domain != "" {
+		e.Type = n.Domain + "-" + n.Family + "-" + n.SubFamily
+	} else {
+		e.Type = n.Proprietary
+	}
+	return e, nil
+}
+
+// entries converts an Ntry element into entries.  A batch booking
+// whose transactions each carry their own amount becomes one entry per
+// transaction.
+func (r *Reader) entries(n *ntry) ([]*qif.Entry, error) {
+	status := n.Status.Code
+	if status == "" {
+		status = strings.TrimSpace(n.Status.Text)
+	}
+	if status == "PDNG" || status == "INFO" {
+		// Pending entries show up again once booked.
+		return nil, nil
+	}
+
+	split := len(n.Details) > 1
+	for i := range n.Details {
+		if n.Details[i].Amount == nil || n.Details[i].CreditDebit == "" {
+			split = false
+		}
+	}
+	if !split {
+		var tx *txDetails
+		if len(n.Details) > 0 {
+			tx = &n.Details[0]
+		}
+		e, err := r.entry(n, tx)
+		if err != nil {
+			return nil, err
+		}
+		return []*qif.Entry{e}, nil
+	}
+	var entries []*qif.Entry
+	for i := range n.Details {
+		e, err := r.entry(n, &n.Details[i])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// ReadEntry reads the next booked entry from the statements or reports
+// in the input, and can be called repeatedly.  Returns (nil, io.EOF) at
+// the end of the input.
+func (r *Reader) ReadEntry() (*qif.Entry, error) {
+	for len(r.queued) == 0 {
+		tok, err := r.d.Token()
+		if err == io.EOF {
+			if r.stmt == nil {
+				return nil, fmt.Errorf("no camt.052 or camt.053 statement found")
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "Stmt", "Rpt":
+			kind := "camt.053"
+			if start.Name.Local == "Rpt" {
+				kind = "camt.052"
+			}
+			r.stmt = &Statement{Kind: kind}
+		case "Id":
+			// The statement's own Id, as opposed to the Ids nested
+			// inside its account and entries.
+			if r.stmt != nil && r.stmt.ID == "" && r.stmt.Account == "" {
+				if err := r.d.DecodeElement(&r.stmt.ID, &start); err != nil {
+					return nil, err
+				}
+			}
+		case "Acct":
+			if r.stmt == nil {
+				continue
+			}
+			var a account
+			if err := r.d.DecodeElement(&a, &start); err != nil {
+				return nil, err
+			}
+			r.stmt.Account = a.IBAN
+			if r.stmt.Account == "" {
+				r.stmt.Account = a.Other
+			}
+			r.stmt.Currency = a.Currency
+		case "Bal":
+			if r.stmt == nil {
+				continue
+			}
+			var b balance
+			if err := r.d.DecodeElement(&b, &start); err != nil {
+				return nil, err
+			}
+			if err := r.balance(&b); err != nil {
+				return nil, err
+			}
+		case "Ntry":
+			if r.stmt == nil {
+				return nil, r.errorf("Ntry outside of a statement")
+			}
+			var n ntry
+			if err := r.d.DecodeElement(&n, &start); err != nil {
+				return nil, err
+			}
+			r.queued, err = r.entries(&n)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	e := r.queued[0]
+	r.queued = r.queued[1:]
+	return e, nil
+}
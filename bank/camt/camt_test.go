@@ -0,0 +1,229 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package camt
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+func date(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+func readAll(t *testing.T, r *Reader) []qif.Entry {
+	t.Helper()
+	var entries []qif.Entry
+	for {
+		e, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+const sample053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt>
+<GrpHdr><MsgId>MSG1</MsgId><CreDtTm>2024-02-01T06:00:00</CreDtTm></GrpHdr>
+<Stmt>
+<Id>STMT-2024-01</Id>
+<CreDtTm>2024-02-01T06:00:00</CreDtTm>
+<Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>EUR</Ccy></Acct>
+<Bal>
+<Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>
+<Amt Ccy="EUR">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+<Dt><Dt>2024-01-01</Dt></Dt>
+</Bal>
+<Bal>
+<Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+<Amt Ccy="EUR">1950.55</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+<Dt><Dt>2024-01-31</Dt></Dt>
+</Bal>
+<Ntry>
+<Amt Ccy="EUR">49.45</Amt>
+<CdtDbtInd>DBIT</CdtDbtInd>
+<Sts>BOOK</Sts>
+<BookgDt><Dt>2024-01-05</Dt></BookgDt>
+<ValDt><Dt>2024-01-06</Dt></ValDt>
+<AcctSvcrRef>BANKREF1</AcctSvcrRef>
+<BkTxCd><Domn><Cd>PMNT</Cd><Fmly><Cd>ICDT</Cd><SubFmlyCd>ESCT</SubFmlyCd></Fmly></Domn></BkTxCd>
+<NtryDtls><TxDtls>
+<Refs><EndToEndId>E2E-1</EndToEndId></Refs>
+<RltdPties>
+<Dbtr><Nm>Me</Nm></Dbtr>
+<Cdtr><Nm>Stadtwerke  Musterstadt</Nm></Cdtr>
+</RltdPties>
+<RmtInf><Ustrd>Invoice 123</Ustrd><Ustrd>January</Ustrd></RmtInf>
+</TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+<Amt Ccy="EUR">1000.000</Amt>
+<CdtDbtInd>CRDT</CdtDbtInd>
+<Sts>BOOK</Sts>
+<BookgDt><DtTm>2024-01-25T10:00:00+01:00</DtTm></BookgDt>
+<AcctSvcrRef>BANKREF2</AcctSvcrRef>
+<NtryDtls><TxDtls>
+<Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+<RltdPties><Dbtr><Nm>ACME GmbH</Nm></Dbtr></RltdPties>
+<RmtInf><Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd></RmtInf>
+</TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+<Amt Ccy="EUR">5.00</Amt>
+<CdtDbtInd>DBIT</CdtDbtInd>
+<Sts>PDNG</Sts>
+<BookgDt><Dt>2024-01-31</Dt></BookgDt>
+</Ntry>
+</Stmt>
+</BkToCstmrStmt>
+</Document>
+`
+
+func TestStatement(t *testing.T) {
+	r := NewReader(strings.NewReader(sample053))
+	entries := readAll(t, r)
+	const iban = "DE89370400440532013000"
+	expects := []qif.Entry{
+		{Number: "BANKREF1", Date: date(2024, 1, 5), ValueDate: date(2024, 1, 6), Amount: -4945, Currency: "EUR",
+			Payee: "Stadtwerke Musterstadt", Memo: "Invoice 123 January", Type: "PMNT-ICDT-ESCT", Cleared: qif.Cleared, Account: iban},
+		{Number: "BANKREF2", Date: date(2024, 1, 25), Amount: 100000, Currency: "EUR",
+			Payee: "ACME GmbH", Memo: "RF18539007547034", Cleared: qif.Cleared, Account: iban},
+	}
+	if len(entries) != len(expects) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(expects))
+	}
+	for i, expect := range expects {
+		if !reflect.DeepEqual(entries[i], expect) {
+			t.Errorf("%d: got\n%#v\nwant\n%#v", i, entries[i], expect)
+		}
+	}
+
+	expect := &Statement{
+		Kind: "camt.053", ID: "STMT-2024-01", Account: iban, Currency: "EUR",
+		Opening: 100000, OpeningDate: date(2024, 1, 1),
+		Closing: 195055, ClosingDate: date(2024, 1, 31),
+	}
+	if stmt := r.Statement(); !reflect.DeepEqual(stmt, expect) {
+		t.Errorf("statement: got\n%#v\nwant\n%#v", stmt, expect)
+	}
+	summary := []string{iban + ": balance 2024/01/01 1000.00, 2024/01/31 1950.55"}
+	if got := r.Summary(); !reflect.DeepEqual(got, summary) {
+		t.Errorf("summary: got %q, want %q", got, summary)
+	}
+}
+
+// A camt.052 report in a later schema version, with a batch booking
+// split into its transactions.
+const sample052 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.052.001.08">
+<BkToCstmrAcctRpt>
+<Rpt>
+<Id>RPT1</Id>
+<Acct><Id><Othr><Id>12345678</Id></Othr></Id></Acct>
+<Ntry>
+<Amt Ccy="CHF">30.00</Amt>
+<CdtDbtInd>DBIT</CdtDbtInd>
+<Sts><Cd>BOOK</Cd></Sts>
+<BookgDt><Dt>2024-03-01</Dt></BookgDt>
+<AcctSvcrRef>BATCHREF</AcctSvcrRef>
+<BkTxCd><Prtry><Cd>BATCH</Cd></Prtry></BkTxCd>
+<NtryDtls>
+<TxDtls>
+<Refs><EndToEndId>A</EndToEndId></Refs>
+<Amt Ccy="CHF">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+<RltdPties><Cdtr><Pty><Nm>First</Nm></Pty></Cdtr></RltdPties>
+</TxDtls>
+<TxDtls>
+<Refs><EndToEndId>B</EndToEndId></Refs>
+<Amt Ccy="CHF">20.00</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+<RltdPties><Cdtr><Pty><Nm>Second</Nm></Pty></Cdtr></RltdPties>
+</TxDtls>
+</NtryDtls>
+<AddtlNtryInf>Sammelauftrag</AddtlNtryInf>
+</Ntry>
+</Rpt>
+</BkToCstmrAcctRpt>
+</Document>
+`
+
+func TestReport(t *testing.T) {
+	r := NewReader(strings.NewReader(sample052))
+	entries := readAll(t, r)
+	expects := []qif.Entry{
+		{Number: "A", Date: date(2024, 3, 1), Amount: -1000, Currency: "CHF",
+			Payee: "First", Memo: "Sammelauftrag", Type: "BATCH", Cleared: qif.Cleared, Account: "12345678"},
+		{Number: "B", Date: date(2024, 3, 1), Amount: -2000, Currency: "CHF",
+			Payee: "Second", Memo: "Sammelauftrag", Type: "BATCH", Cleared: qif.Cleared, Account: "12345678"},
+	}
+	if !reflect.DeepEqual(entries, expects) {
+		t.Errorf("got\n%#v\nwant\n%#v", entries, expects)
+	}
+	if kind := r.Statement().Kind; kind != "camt.052" {
+		t.Errorf("kind: got %q", kind)
+	}
+}
+
+func TestNotCamt(t *testing.T) {
+	r := NewReader(strings.NewReader(`<?xml version="1.0"?><OFX></OFX>`))
+	if _, err := r.ReadEntry(); err == nil || err == io.EOF {
+		t.Errorf("expected error, got %v", err)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	const doc = `<?xml version="1.0"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt><Stmt>
+<Ntry>
+<Amt Ccy="EUR">1.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+<BookgDt><Dt>2024-01-05</Dt></BookgDt>
+<AcctSvcrRef>NOTPROVIDED</AcctSvcrRef>
+<NtryDtls><TxDtls><Refs><EndToEndId>E2E-1</EndToEndId></Refs></TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+<Amt Ccy="EUR">2.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+<BookgDt><Dt>2024-01-05</Dt></BookgDt>
+<AcctSvcrRef>BANKREF</AcctSvcrRef>
+<NtryDtls><TxDtls><Refs><AcctSvcrRef>TXREF</AcctSvcrRef><EndToEndId>E2E-2</EndToEndId></Refs></TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+<NtryRef>NTRYREF</NtryRef>
+<Amt Ccy="EUR">3.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+<BookgDt><Dt>2024-01-05</Dt></BookgDt>
+<NtryDtls><TxDtls><Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs></TxDtls></NtryDtls>
+</Ntry>
+</Stmt></BkToCstmrStmt>
+</Document>
+`
+	var numbers []string
+	for _, e := range readAll(t, NewReader(strings.NewReader(doc))) {
+		numbers = append(numbers, e.Number)
+	}
+	expect := []string{"E2E-1", "TXREF", "NTRYREF"}
+	if !reflect.DeepEqual(numbers, expect) {
+		t.Errorf("got %q, want %q", numbers, expect)
+	}
+}
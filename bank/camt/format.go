@@ -0,0 +1,54 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package camt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/evmar/fin/bank"
+	"github.com/evmar/fin/bank/money"
+)
+
+func init() {
+	bank.Register(&bank.Format{
+		Name:        "camt",
+		Description: "ISO 20022 camt.053 statements and camt.052 reports",
+		Extensions:  []string{".xml"},
+		Sniff:       sniff,
+		Open:        open,
+	})
+}
+
+// sniff recognizes camt by the namespace of its XML document.
+func sniff(prefix []byte) bool {
+	return bytes.Contains(prefix, []byte("<Document")) &&
+		(bytes.Contains(prefix, []byte(":camt.052.")) || bytes.Contains(prefix, []byte(":camt.053.")))
+}
+
+func open(r io.Reader, opts *bank.Options) (bank.Reader, error) {
+	return NewReader(r), nil
+}
+
+// Summary returns the balances of the last statement read.
+func (r *Reader) Summary() []string {
+	if r.stmt == nil || r.stmt.ClosingDate.IsZero() {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: balance %s %s, %s %s", r.stmt.Account,
+		r.stmt.OpeningDate.Format("2006/01/02"), money.Format(r.stmt.Opening),
+		r.stmt.ClosingDate.Format("2006/01/02"), money.Format(r.stmt.Closing))}
+}
@@ -0,0 +1,340 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package charset decodes bank statement files to UTF-8.  Banks export
+// in whatever encoding their software uses, often without saying so,
+// so the encoding is usually detected.
+package charset
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset is a character encoding.
+type Charset string
+
+const (
+	UTF8        Charset = "utf-8"
+	UTF16LE     Charset = "utf-16le"
+	UTF16BE     Charset = "utf-16be"
+	Windows1252 Charset = "windows-1252"
+	Latin1      Charset = "iso-8859-1"
+)
+
+// Lookup returns the charset with the given name or alias, such as
+// "UTF-8", "cp1252", or "latin1".
+func Lookup(name string) (Charset, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "utf-8", "utf8", "unicode":
+		return UTF8, true
+	case "utf-16le", "utf16le":
+		return UTF16LE, true
+	case "utf-16be", "utf16be", "utf-16", "utf16":
+		// Without a byte order mark, UTF-16 is big-endian.
+		return UTF16BE, true
+	case "windows-1252", "cp1252", "1252":
+		return Windows1252, true
+	case "us-ascii", "usascii", "ascii":
+		// Files that claim to be ASCII are often really 1252, which
+		// is a superset.
+		return Windows1252, true
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "l1":
+		return Latin1, true
+	}
+	return "", false
+}
+
+// sniffLen is how much of the input is examined to detect its charset.
+const sniffLen = 64 * 1024
+
+// Detect guesses the charset of text that starts with prefix:
+//
+//   - a byte order mark gives UTF-8 or UTF-16;
+//   - text with ASCII in every other byte is UTF-16;
+//   - text that is valid UTF-8 is UTF-8;
+//   - otherwise, the charset declared in an OFX header or XML
+//     declaration, if any, is used;
+//   - otherwise, text with bytes 0x80-0x9f, which are control codes in
+//     Latin-1 but punctuation such as curly quotes in Windows-1252, is
+//     Windows-1252, and the rest is Latin-1.
+//
+// A declared charset is only used for text that isn't UTF-8, because
+// banks often declare 1252 but write UTF-8.
+func Detect(prefix []byte) Charset {
+	return detect(prefix, true)
+}
+
+// detect is Detect, where partial is whether more text follows prefix.
+func detect(prefix []byte, partial bool) Charset {
+	switch {
+	case bytes.HasPrefix(prefix, []byte("\xef\xbb\xbf")):
+		return UTF8
+	case bytes.HasPrefix(prefix, []byte("\xff\xfe")):
+		return UTF16LE
+	case bytes.HasPrefix(prefix, []byte("\xfe\xff")):
+		return UTF16BE
+	}
+	if cs := detectUTF16(prefix); cs != "" {
+		return cs
+	}
+	if validUTF8(prefix, partial) {
+		return UTF8
+	}
+	if cs, ok := declared(prefix); ok {
+		return cs
+	}
+	for _, b := range prefix {
+		if b >= 0x80 && b <= 0x9f {
+			return Windows1252
+		}
+	}
+	return Latin1
+}
+
+// detectUTF16 detects UTF-16 without a byte order mark by the zero high
+// bytes of ASCII text.
+func detectUTF16(prefix []byte) Charset {
+	if len(prefix) > 512 {
+		prefix = prefix[:512]
+	}
+	if len(prefix) < 4 {
+		return ""
+	}
+	var even, odd int
+	for i, b := range prefix {
+		if b == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	pairs := len(prefix) / 2
+	switch {
+	case odd > pairs*3/4 && even == 0:
+		return UTF16LE
+	case even > pairs*3/4 && odd == 0:
+		return UTF16BE
+	}
+	return ""
+}
+
+// validUTF8 is like utf8.Valid, but if partial, allows prefix to end
+// partway through a character.
+func validUTF8(prefix []byte, partial bool) bool {
+	for len(prefix) > 0 {
+		r, size := utf8.DecodeRune(prefix)
+		if r == utf8.RuneError && size == 1 {
+			return partial && !utf8.FullRune(prefix)
+		}
+		prefix = prefix[size:]
+	}
+	return true
+}
+
+var (
+	ofxEncodingRE = regexp.MustCompile(`(?m)^\s*ENCODING:\s*(\S+)`)
+	ofxCharsetRE  = regexp.MustCompile(`(?m)^\s*CHARSET:\s*(\S+)`)
+	xmlEncodingRE = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding=["']([^"']+)["']`)
+)
+
+// declared returns the charset declared by an OFX 1.x header or an XML
+// declaration at the start of prefix.
+func declared(prefix []byte) (Charset, bool) {
+	if len(prefix) > 1024 {
+		prefix = prefix[:1024]
+	}
+	if m := xmlEncodingRE.FindSubmatch(prefix); m != nil {
+		return Lookup(string(m[1]))
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(prefix), []byte("OFXHEADER")) {
+		return "", false
+	}
+	// ENCODING is USASCII or UTF-8; for USASCII, CHARSET names the
+	// code page.
+	if m := ofxEncodingRE.FindSubmatch(prefix); m != nil && !bytes.EqualFold(m[1], []byte("USASCII")) {
+		return Lookup(string(m[1]))
+	}
+	if m := ofxCharsetRE.FindSubmatch(prefix); m != nil {
+		return Lookup(string(m[1]))
+	}
+	return "", false
+}
+
+// windows1252 maps bytes 0x80-0x9f to runes; the rest of Windows-1252
+// matches Latin-1.  Undefined bytes map to the same code points as in
+// Latin-1.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+func decode1252(b byte) rune {
+	if b >= 0x80 && b <= 0x9f {
+		return windows1252[b-0x80]
+	}
+	return rune(b)
+}
+
+// Encode1252 returns the Windows-1252 byte for r, for writers of
+// formats such as QIF that programs expect in it.  ok is false if
+// Windows-1252 has no such character.
+func Encode1252(r rune) (b byte, ok bool) {
+	if r < 0x80 || (r >= 0xa0 && r <= 0xff) {
+		return byte(r), true
+	}
+	for i, wr := range windows1252 {
+		if wr == r {
+			return byte(0x80 + i), true
+		}
+	}
+	return 0, false
+}
+
+// Reader decodes its input to UTF-8.
+type Reader struct {
+	r       *bufio.Reader
+	charset Charset
+	// started is set once any byte order mark has been skipped.
+	started bool
+	// out holds decoded bytes not yet returned by Read.
+	out []byte
+}
+
+// NewReader returns a reader that decodes r from the charset cs, or
+// from the charset detected by Detect if cs is empty.  If r is already
+// a Reader with no charset given, it is returned as-is.
+func NewReader(r io.Reader, cs Charset) *Reader {
+	if cr, ok := r.(*Reader); ok && cs == "" {
+		return cr
+	}
+	return &Reader{r: bufio.NewReaderSize(r, sniffLen), charset: cs}
+}
+
+// Charset returns the charset of the input, detecting it if needed.
+func (r *Reader) Charset() (Charset, error) {
+	if err := r.start(); err != nil {
+		return "", err
+	}
+	return r.charset, nil
+}
+
+func (r *Reader) start() error {
+	if r.started {
+		return nil
+	}
+	prefix, err := r.r.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return err
+	}
+	if r.charset == "" {
+		r.charset = detect(prefix, err == nil)
+	}
+	var bom string
+	switch r.charset {
+	case UTF8:
+		bom = "\xef\xbb\xbf"
+	case UTF16LE:
+		bom = "\xff\xfe"
+	case UTF16BE:
+		bom = "\xfe\xff"
+	}
+	if bom != "" && bytes.HasPrefix(prefix, []byte(bom)) {
+		r.r.Discard(len(bom))
+	}
+	r.started = true
+	return nil
+}
+
+// readRune reads and decodes one character.
+func (r *Reader) readRune() (rune, error) {
+	switch r.charset {
+	case UTF8:
+		peek, err := r.r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		b := peek[0]
+		c, size, err := r.r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if c == utf8.RuneError && size == 1 {
+			// Stray bytes in otherwise UTF-8 text are most likely
+			// Windows-1252.
+			return decode1252(b), nil
+		}
+		return c, nil
+	case UTF16LE, UTF16BE:
+		u, err := r.readUnit()
+		if err != nil {
+			return 0, err
+		}
+		if !utf16.IsSurrogate(rune(u)) {
+			return rune(u), nil
+		}
+		u2, err := r.readUnit()
+		if err != nil {
+			return utf8.RuneError, nil
+		}
+		return utf16.DecodeRune(rune(u), rune(u2)), nil
+	case Windows1252:
+		b, err := r.r.ReadByte()
+		return decode1252(b), err
+	default:
+		b, err := r.r.ReadByte()
+		return rune(b), err
+	}
+}
+
+// readUnit reads one UTF-16 code unit.
+func (r *Reader) readUnit() (uint16, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(r.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return 0, err
+	}
+	if r.charset == UTF16LE {
+		return uint16(b[0]) | uint16(b[1])<<8, nil
+	}
+	return uint16(b[0])<<8 | uint16(b[1]), nil
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if err := r.start(); err != nil {
+		return 0, err
+	}
+	for len(r.out) < len(p) {
+		c, err := r.readRune()
+		if err != nil {
+			if len(r.out) > 0 {
+				break
+			}
+			return 0, err
+		}
+		r.out = utf8.AppendRune(r.out, c)
+	}
+	n := copy(p, r.out)
+	r.out = r.out[:copy(r.out, r.out[n:])]
+	return n, nil
+}
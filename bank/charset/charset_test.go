@@ -0,0 +1,106 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charset
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		charset Charset
+		expect  string
+	}{
+		{"ascii", "PCAFE", UTF8, "PCAFE"},
+		{"utf-8", "PCaf\xc3\xa9", UTF8, "PCafé"},
+		{"utf-8 bom", "\xef\xbb\xbfStatus,Date", UTF8, "Status,Date"},
+		{"utf-16le bom", "\xff\xfeS\x00t\x00\xe9\x00", UTF16LE, "Sté"},
+		{"utf-16be bom", "\xfe\xff\x00S\x00t\x00\xe9", UTF16BE, "Sté"},
+		{"utf-16le", "S\x00t\x00a\x00t\x00u\x00s\x00", UTF16LE, "Status"},
+		{"utf-16 surrogates", "\xff\xfe\x3d\xd8\x00\xde", UTF16LE, "😀"},
+		{"latin-1", "PCaf\xe9", Latin1, "PCafé"},
+		{"windows-1252", "P\x93Caf\xe9\x94 \x80", Windows1252, "P“Café” €"},
+		{"ofx charset", "OFXHEADER:100\nENCODING:USASCII\nCHARSET:ISO-8859-1\n\n<NAME>Caf\xe9 \x80", Latin1, "OFXHEADER:100\nENCODING:USASCII\nCHARSET:ISO-8859-1\n\n<NAME>Café \u0080"},
+		{"ofx utf-8 despite charset", "OFXHEADER:100\nENCODING:USASCII\nCHARSET:1252\n\n<NAME>Caf\xc3\xa9", UTF8, "OFXHEADER:100\nENCODING:USASCII\nCHARSET:1252\n\n<NAME>Café"},
+		{"xml declaration", `<?xml version="1.0" encoding="ISO-8859-1"?><Nm>Caf` + "\xe9\x80</Nm>", Latin1, `<?xml version="1.0" encoding="ISO-8859-1"?><Nm>Café` + "\u0080</Nm>"},
+	}
+	for _, test := range tests {
+		r := NewReader(strings.NewReader(test.input), "")
+		cs, err := r.Charset()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cs != test.charset {
+			t.Errorf("%s: detected %s, want %s", test.name, cs, test.charset)
+		}
+		out, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != test.expect {
+			t.Errorf("%s: got %q, want %q", test.name, out, test.expect)
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	out, err := io.ReadAll(NewReader(strings.NewReader("Caf\xc3\xa9"), Latin1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "CafÃ©" {
+		t.Errorf("got %q", out)
+	}
+}
+
+// Text detected as UTF-8 from its start may still have stray bytes
+// further on.
+func TestStrayBytes(t *testing.T) {
+	input := strings.Repeat("a", sniffLen) + "\x93ok\x94 \xc3\xa9"
+	out, err := io.ReadAll(NewReader(strings.NewReader(input), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out[sniffLen:]); got != "“ok” é" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for name, expect := range map[string]Charset{"UTF-8": UTF8, "cp1252": Windows1252, "1252": Windows1252, "Latin1": Latin1} {
+		if cs, ok := Lookup(name); !ok || cs != expect {
+			t.Errorf("%s: got %s, %v", name, cs, ok)
+		}
+	}
+	if _, ok := Lookup("ebcdic"); ok {
+		t.Errorf("ebcdic: expected unknown")
+	}
+}
+
+func TestEncode1252(t *testing.T) {
+	for r := rune(0); r <= 0xff; r++ {
+		b, ok := Encode1252(decode1252(byte(r)))
+		if !ok || b != byte(r) {
+			t.Errorf("%#x: got %#x, %v", r, b, ok)
+		}
+	}
+	if _, ok := Encode1252('中'); ok {
+		t.Errorf("中: expected no encoding")
+	}
+}
@@ -1,127 +1,324 @@
-// Package CSV provides a reader that converts a Citi CSV export into
+// Package CSV provides a reader that converts bank CSV exports into
 // QIF entries.  (Why? The Citi QIF export has truncated fields.)
+//
+// Each bank lays out its CSV differently, so the reader is driven by a
+// Profile describing which columns hold what.  The profile is either
+// given explicitly or detected from the header row.
 package csv
 
 import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/dates"
+	"github.com/evmar/fin/bank/money"
 	"github.com/evmar/fin/bank/qif"
 )
 
-type mode int
-
-const (
-	Citi mode = iota
-	Venmo
-)
-
 type CSVReader struct {
-	r      *csv.Reader
-	mode   mode
-	fields map[string]int
+	// DateOrder is the order of fields in the input's dates.  It is
+	// initialized from the profile and defaults to dates.MDY.  It has
+	// no effect if the profile's DateFormat is a time layout.
+	DateOrder dates.Order
+
+	r       *csv.Reader
+	queued  [][]string // rows read during detection
+	pending []*qif.Entry
+	profile *Profile
+	layout  string
+	fields  map[string]int
 }
 
+// detectRows is how many rows at the start of a file are searched for
+// a header row.
+const detectRows = 10
+
+// NewCSVReader constructs a CSVReader, detecting which of the built-in
+// profiles the input matches.
 func NewCSVReader(r io.Reader) (*CSVReader, error) {
-	cr := &CSVReader{r: csv.NewReader(r), fields: map[string]int{}}
-	for {
-		fields := map[string]int{}
-		header, err := cr.r.Read()
+	return Detect(r, nil)
+}
+
+// Detect constructs a CSVReader, detecting which profile the input
+// matches by looking for a header row with a profile's signature.
+// The given profiles are considered along with the built-in ones, and
+// the most specific match wins.
+func Detect(r io.Reader, profiles []*Profile) (*CSVReader, error) {
+	cr := &CSVReader{r: csv.NewReader(charset.NewReader(r, ""))}
+	cr.r.FieldsPerRecord = -1
+
+	var rows [][]string
+	for len(rows) < detectRows {
+		row, err := cr.r.Read()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return nil, err
 		}
-		for i, name := range header {
-			if name == "" {
-				continue
+		rows = append(rows, row)
+	}
+
+	var best *Profile
+	bestScore, bestRow := 0, 0
+	for i, row := range rows {
+		for _, list := range [][]*Profile{profiles, Builtin} {
+			for _, p := range list {
+				if p.sniff != nil && i > 0 {
+					continue
+				}
+				if ok, score := p.matches(row); ok && score > bestScore {
+					best, bestScore, bestRow = p, score, i
+				}
 			}
-			fields[name] = i
 		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("csv: unrecognized format; saw columns %s", describeColumns(rows))
+	}
 
-		// venmo CSV starts with a few rows of junk, skip them
-		if len(fields) > 1 {
-			cr.fields = fields
-			break
-		}
-		cr.mode = Venmo
+	if best.NoHeader {
+		cr.queued = rows[bestRow:]
+	} else {
+		cr.readHeader(rows[bestRow])
+		cr.queued = rows[bestRow+1:]
+	}
+	if err := cr.setProfile(best); err != nil {
+		return nil, err
 	}
 	return cr, nil
 }
 
-func parseNumber(num string) (int, error) {
-	num = strings.Replace(num, ",", "", -1)
-	num = strings.Replace(num, " ", "", -1)
-	num = strings.Replace(num, "$", "", -1)
-	numF, err := strconv.ParseFloat(num, 64)
-	if err != nil {
-		return 0, err
+// describeColumns describes the row that looks most like a header, for
+// error messages.
+func describeColumns(rows [][]string) string {
+	var header []string
+	for _, row := range rows {
+		var names []string
+		for _, name := range row {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, fmt.Sprintf("%q", name))
+			}
+		}
+		if len(names) > len(header) {
+			header = names
+		}
+	}
+	if len(header) == 0 {
+		return "(none)"
 	}
-	return int(numF * 100.0), nil
+	return strings.Join(header, ", ")
 }
 
-func (cr *CSVReader) ReadEntry() (*qif.Entry, error) {
-	switch cr.mode {
-	case Citi:
-		row, err := cr.r.Read()
-		if err != nil {
+// NewReader constructs a CSVReader for input laid out as described by
+// profile.
+func NewReader(r io.Reader, profile *Profile) (*CSVReader, error) {
+	if err := profile.check(); err != nil {
+		return nil, err
+	}
+	cr := &CSVReader{r: csv.NewReader(charset.NewReader(r, ""))}
+	cr.r.FieldsPerRecord = -1
+	for i := 0; i < profile.Skip; i++ {
+		if _, err := cr.r.Read(); err != nil {
 			return nil, err
 		}
-
-		e := &qif.Entry{Cleared: qif.Cleared}
-		e.Date, err = time.Parse("01/02/2006", row[cr.fields["Date"]])
+	}
+	if !profile.NoHeader {
+		header, err := cr.r.Read()
 		if err != nil {
 			return nil, err
 		}
+		cr.readHeader(header)
+	}
+	if err := cr.setProfile(profile); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
 
-		if n := row[cr.fields["Credit"]]; n != "" {
-			e.Amount, err = parseNumber(n)
-			if err != nil {
-				return nil, err
-			}
-		} else if n := row[cr.fields["Debit"]]; n != "" {
-			e.Amount, err = parseNumber(n)
-			if err != nil {
-				return nil, err
-			}
+// readHeader records the column names from the header row, and
+// returns how many there are.
+func (cr *CSVReader) readHeader(header []string) int {
+	cr.fields = map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
 		}
-		e.Amount = -e.Amount
+		cr.fields[name] = i
+	}
+	return len(cr.fields)
+}
 
-		e.Payee = strings.TrimSpace(row[cr.fields["Description"]])
-		return e, nil
-	case Venmo:
-		for {
-			row, err := cr.r.Read()
-			if err != nil {
-				return nil, err
-			}
+// setProfile resolves the profile's columns against the header.
+func (cr *CSVReader) setProfile(p *Profile) error {
+	cr.profile = p
+	cr.DateOrder = dates.MDY
+	if p.DateFormat != "" {
+		if order, err := dates.ParseOrder(p.DateFormat); err == nil {
+			cr.DateOrder = order
+		} else {
+			cr.layout = p.DateFormat
+		}
+	}
 
-			if row[cr.fields["Type"]] != "Payment" { // venmo payments
-				continue
-			}
+	if p.NoHeader {
+		return nil
+	}
+	var missing []string
+	for _, c := range p.columns() {
+		if c.Name == "" {
+			continue
+		}
+		if _, ok := cr.fields[c.Name]; !ok {
+			missing = append(missing, c.String())
+		}
+	}
+	for name := range p.Filter {
+		if _, ok := cr.fields[name]; !ok {
+			missing = append(missing, fmt.Sprintf("%q", name))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("csv %s: header is missing columns %s", p.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
 
-			e := &qif.Entry{Cleared: qif.Cleared}
+// get returns the value in row for a column, or "" if the row is too
+// short or the column isn't set.
+func (cr *CSVReader) get(row []string, c *Column) string {
+	if c == nil {
+		return ""
+	}
+	i := c.Index
+	if c.Name != "" {
+		i = cr.fields[c.Name]
+	}
+	if i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
 
-			date := row[cr.fields["Datetime"]]
-			if date == "" {
-				continue // more junk in venmo csv
-			}
-			e.Date, err = time.Parse("2006-01-02T15:04:05", date)
-			if err != nil {
-				return nil, err
+func (cr *CSVReader) keep(row []string) bool {
+	for name, values := range cr.profile.Filter {
+		value := cr.get(row, Col(name))
+		found := false
+		for _, v := range values {
+			if v == value {
+				found = true
 			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
 
-			e.Amount, err = parseNumber(row[cr.fields["Amount (total)"]])
-			if err != nil {
-				return nil, err
-			}
+func (cr *CSVReader) amount(row []string) (int, error) {
+	p := cr.profile
+	if p.Amount != nil {
+		return money.Parse(cr.get(row, p.Amount))
+	}
+
+	// Some banks write the debit and credit columns unsigned and some
+	// signed, so only the magnitude matters.
+	abs := func(n int) int {
+		if n < 0 {
+			return -n
+		}
+		return n
+	}
+	if n := cr.get(row, p.Credit); n != "" {
+		amount, err := money.Parse(n)
+		return abs(amount), err
+	}
+	if n := cr.get(row, p.Debit); n != "" {
+		amount, err := money.Parse(n)
+		return -abs(amount), err
+	}
+	return 0, nil
+}
+
+// Profile returns the profile the reader is using.
+func (cr *CSVReader) Profile() *Profile {
+	return cr.profile
+}
 
-			e.Payee = fmt.Sprintf("%s: %s", row[cr.fields["To"]], row[cr.fields["Note"]])
+func (cr *CSVReader) read() ([]string, error) {
+	if len(cr.queued) > 0 {
+		row := cr.queued[0]
+		cr.queued = cr.queued[1:]
+		return row, nil
+	}
+	return cr.r.Read()
+}
 
-			return e, nil
+func (cr *CSVReader) parseDate(date string) (time.Time, error) {
+	if cr.layout != "" {
+		return time.Parse(cr.layout, date)
+	}
+	return dates.Parse(date, cr.DateOrder)
+}
+
+// entries converts a row into entries as described by the profile.
+func (cr *CSVReader) entries(row []string) ([]*qif.Entry, error) {
+	p := cr.profile
+	if p.convert != nil {
+		return p.convert(cr, row)
+	}
+
+	e := &qif.Entry{Cleared: qif.Cleared}
+	var err error
+	e.Date, err = cr.parseDate(cr.get(row, p.Date))
+	if err != nil {
+		return nil, err
+	}
+
+	e.Amount, err = cr.amount(row)
+	if err != nil {
+		return nil, err
+	}
+	if p.Negate {
+		e.Amount = -e.Amount
+	}
+
+	var payee []string
+	for _, c := range p.Payee {
+		if v := cr.get(row, c); v != "" {
+			payee = append(payee, v)
+		}
+	}
+	e.Payee = strings.Join(payee, ": ")
+	e.Memo = cr.get(row, p.Memo)
+	e.Number = cr.get(row, p.Number)
+	e.Currency = strings.ToUpper(cr.get(row, p.Currency))
+	return []*qif.Entry{e}, nil
+}
+
+func (cr *CSVReader) ReadEntry() (*qif.Entry, error) {
+	for len(cr.pending) == 0 {
+		row, err := cr.read()
+		if err != nil {
+			return nil, err
+		}
+
+		if cr.get(row, cr.profile.Date) == "" || !cr.keep(row) {
+			// Balance rows and other junk.
+			continue
+		}
+		cr.pending, err = cr.entries(row)
+		if err != nil {
+			return nil, err
 		}
 	}
-	panic("unreachable")
+	e := cr.pending[0]
+	cr.pending = cr.pending[1:]
+	return e, nil
 }
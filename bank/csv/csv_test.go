@@ -2,6 +2,9 @@ package csv
 
 import (
 	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -39,15 +42,17 @@ func TestCiti(t *testing.T) {
 		`"Cleared","08/04/2015","FOREIGN TRANSACTION FEE                 
 ","3,117.29",""` + "\r\n" +
 		`"Cleared","08/03/2015","GOOGLE *Music          GOOGLE.COM/CH CA 
-","",""` + "\r\n"
+","",""` + "\r\n" +
+		`"Cleared","08/02/2015","PAYMENT THANK YOU","","-500.00"` + "\r\n"
 
 	entries, err := parseAll(input)
 	if err != nil {
 		panic(err)
 	}
 	expects := []qif.Entry{
-		{Date: date(2015, 8, 4), Amount: 311729, Payee: "FOREIGN TRANSACTION FEE", Cleared: 1},
+		{Date: date(2015, 8, 4), Amount: -311729, Payee: "FOREIGN TRANSACTION FEE", Cleared: 1},
 		{Date: date(2015, 8, 3), Amount: 0, Payee: "GOOGLE *Music          GOOGLE.COM/CH CA", Cleared: 1},
+		{Date: date(2015, 8, 2), Amount: 50000, Payee: "PAYMENT THANK YOU", Cleared: 1},
 	}
 
 	if len(entries) != len(expects) {
@@ -55,7 +60,7 @@ func TestCiti(t *testing.T) {
 	}
 
 	for i, expect := range expects {
-		if entries[i] != expect {
+		if !reflect.DeepEqual(entries[i], expect) {
 			t.Errorf("%d: got\n%#v\nwant\n%#v", i, entries[i], expect)
 		}
 	}
@@ -69,6 +74,10 @@ Account Activity,,,,,,,,,,,,,,,,,,,,,
 ,1,2024-04-02T11:12:13,Payment,Complete,Foobar,My Name,Other,- $175.00,,0,,0,,"BANK OF AMERICA, N.A. (SFNB) Personal Checking *1111",,,,,Venmo,,
 ,2,2024-04-11T07:28:52,Charge,Complete,Foobar2,Other,My Name,- $175.00,,0,,0,,"BANK OF AMERICA, N.A. (SFNB) Personal Checking *1111",,,,,Venmo,,
 ,3,2024-04-30T20:00:14,Payment,Complete,Foobar3,My Name,Other,- $175.00,,0,,0,,"BANK OF AMERICA, N.A. (SFNB) Personal Checking *1111",,,,,Venmo,,
+,4,2024-04-15T09:00:00,Charge,Complete,Dinner,My Name,Friend,+ $30.00,,0,,0,,,Venmo balance,,,,,,
+,5,2024-04-20T10:00:00,Instant Transfer,Issued,,,,- $100.00,,0,- $1.75,0,,,"BANK OF AMERICA, N.A. (SFNB) Personal Checking *1111",,,,,,
+,6,2024-04-21T10:00:00,Payment,Pending,Later,My Name,Other,- $5.00,,0,,0,,Venmo balance,,,,,,,
+,,,,,,,,,,,,,,,,,$12.46,,,,
 `
 
 	entries, err := parseAll(input)
@@ -76,16 +85,145 @@ Account Activity,,,,,,,,,,,,,,,,,,,,,
 		panic(err)
 	}
 	expects := []qif.Entry{
-		{Date: time.Date(2024, time.April, 2, 11, 12, 13, 0, time.UTC), Amount: -17500, Payee: "Other: Foobar", Cleared: 1},
-		{Date: time.Date(2024, time.April, 30, 20, 0, 14, 0, time.UTC), Amount: -17500, Payee: "Other: Foobar3", Cleared: 1},
+		{Number: "1", Date: time.Date(2024, time.April, 2, 11, 12, 13, 0, time.UTC), Amount: -17500, Payee: "Other", From: "My Name", To: "Other", Memo: "Foobar", Type: "Payment", Cleared: 1},
+		{Number: "2", Date: time.Date(2024, time.April, 11, 7, 28, 52, 0, time.UTC), Amount: -17500, Payee: "Other", From: "Other", To: "My Name", Memo: "Foobar2", Type: "Charge", Cleared: 1},
+		{Number: "3", Date: time.Date(2024, time.April, 30, 20, 0, 14, 0, time.UTC), Amount: -17500, Payee: "Other", From: "My Name", To: "Other", Memo: "Foobar3", Type: "Payment", Cleared: 1},
+		{Number: "4", Date: time.Date(2024, time.April, 15, 9, 0, 0, 0, time.UTC), Amount: 3000, Payee: "Friend", From: "My Name", To: "Friend", Memo: "Dinner", Type: "Charge", Cleared: 1},
+		{Number: "5", Date: time.Date(2024, time.April, 20, 10, 0, 0, 0, time.UTC), Amount: -9825, Payee: "BANK OF AMERICA, N.A. (SFNB) Personal Checking *1111", Memo: "Instant Transfer", Type: "Instant Transfer", Cleared: 1},
+		{Number: "5-fee", Date: time.Date(2024, time.April, 20, 10, 0, 0, 0, time.UTC), Amount: -175, Payee: "Venmo", Memo: "Instant Transfer fee", Type: "Fee", Cleared: 1},
 	}
 
 	if len(entries) != len(expects) {
 		t.Fatalf("got %d entries, want %d", len(entries), len(expects))
 	}
 	for i, expect := range expects {
-		if entries[i] != expect {
+		if !reflect.DeepEqual(entries[i], expect) {
 			t.Errorf("%d: got\n%#v\nwant\n%#v", i, entries[i], expect)
 		}
 	}
 }
+
+func TestProfile(t *testing.T) {
+	const profiles = `[{
+		"name": "mybank",
+		"skip": 1,
+		"date": "Posted",
+		"dateFormat": "dmy",
+		"amount": 2,
+		"negate": true,
+		"payee": ["Merchant"],
+		"memo": "Details",
+		"filter": {"State": ["Posted", "Pending"]}
+	}]`
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte(profiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := FindProfile("mybank", ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const input = `My Bank export
+Posted,Merchant,Amount,Details,State
+31/01/2020,CORNER SHOP,"1.234,50",groceries,Posted
+01/02/2020,IGNORED,1.00,,Declined
+,,,,
+`
+	cr, err := NewReader(strings.NewReader(input), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := cr.ReadEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := qif.Entry{Date: date(2020, 1, 31), Amount: -123450, Payee: "CORNER SHOP", Memo: "groceries", Cleared: 1}
+	if !reflect.DeepEqual(*e, expect) {
+		t.Errorf("got\n%#v\nwant\n%#v", *e, expect)
+	}
+	if _, err := cr.ReadEntry(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestMissingColumn(t *testing.T) {
+	_, err := NewReader(strings.NewReader("Date,Amount\n"), Citi)
+	if err == nil || !strings.Contains(err.Error(), `"Debit"`) {
+		t.Errorf("expected missing column error, got %v", err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		profile string
+		input   string
+		expect  qif.Entry
+	}{
+		{"chase", "Transaction Date,Post Date,Description,Category,Type,Amount,Memo\n" +
+			"01/30/2024,01/31/2024,STARBUCKS,Food & Drink,Sale,-5.25,\n",
+			qif.Entry{Date: date(2024, 1, 30), Amount: -525, Payee: "STARBUCKS", Cleared: 1}},
+		{"amex", "Date,Description,Card Member,Account #,Amount\n" +
+			"01/30/2024,DELTA AIR LINES,JANE DOE,-11005,412.30\n",
+			qif.Entry{Date: date(2024, 1, 30), Amount: -41230, Payee: "DELTA AIR LINES", Cleared: 1}},
+		{"capitalone", "Transaction Date,Posted Date,Card No.,Description,Category,Debit,Credit\n" +
+			"2024-01-30,2024-01-31,1234,CAPITAL ONE AUTOPAY PYMT,Payment/Credit,,100.00\n",
+			qif.Entry{Date: date(2024, 1, 30), Amount: 10000, Payee: "CAPITAL ONE AUTOPAY PYMT", Cleared: 1}},
+		{"discover", "Trans. Date,Post Date,Description,Amount,Category\n" +
+			"01/30/2024,01/30/2024,SAFEWAY #1234,54.10,Supermarkets\n",
+			qif.Entry{Date: date(2024, 1, 30), Amount: -5410, Payee: "SAFEWAY #1234", Cleared: 1}},
+		{"applecard", "Transaction Date,Clearing Date,Description,Merchant,Category,Type,Amount (USD),Purchased By\n" +
+			"01/30/2024,01/31/2024,\"APPLE.COM/BILL ONE APPLE PARK\",Apple Services,Other,Purchase,0.99,Jane Doe\n",
+			qif.Entry{Date: date(2024, 1, 30), Amount: -99, Payee: "Apple Services", Memo: "APPLE.COM/BILL ONE APPLE PARK", Cleared: 1}},
+		{"wellsfargo", "\"01/30/2024\",\"-12.34\",\"*\",\"\",\"PURCHASE AUTHORIZED ON 01/29 CORNER STORE\"\n",
+			qif.Entry{Date: date(2024, 1, 30), Amount: -1234, Payee: "PURCHASE AUTHORIZED ON 01/29 CORNER STORE", Cleared: 1}},
+		{"paypal", "\"Date\",\"Time\",\"TimeZone\",\"Name\",\"Type\",\"Status\",\"Currency\",\"Gross\",\"Fee\",\"Net\",\"Transaction ID\"\n" +
+			"\"01/30/2024\",\"10:00:00\",\"PST\",\"\",\"General Authorization\",\"Pending\",\"USD\",\"-20.00\",\"0.00\",\"-20.00\",\"1AB\"\n" +
+			"\"01/30/2024\",\"10:00:01\",\"PST\",\"Some Shop\",\"Express Checkout Payment\",\"Completed\",\"USD\",\"-20.00\",\"0.00\",\"-20.00\",\"2CD\"\n",
+			qif.Entry{Date: date(2024, 1, 30), Amount: -2000, Payee: "Some Shop", Memo: "Express Checkout Payment", Number: "2CD", Currency: "USD", Cleared: 1}},
+	}
+	for _, test := range tests {
+		cr, err := NewCSVReader(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: %v", test.profile, err)
+			continue
+		}
+		if name := cr.Profile().Name; name != test.profile {
+			t.Errorf("%s: detected %s", test.profile, name)
+		}
+		e, err := cr.ReadEntry()
+		if err != nil {
+			t.Errorf("%s: %v", test.profile, err)
+			continue
+		}
+		if !reflect.DeepEqual(*e, test.expect) {
+			t.Errorf("%s: got\n%#v\nwant\n%#v", test.profile, *e, test.expect)
+		}
+	}
+}
+
+func TestDetectUnknown(t *testing.T) {
+	_, err := NewCSVReader(strings.NewReader("When,What,How Much\n01/02/2024,Stuff,1.00\n"))
+	if err == nil || !strings.Contains(err.Error(), `saw columns "When", "What", "How Much"`) {
+		t.Errorf("expected unrecognized format error, got %v", err)
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	const input = "\xef\xbb\xbf" + `"Status","Date","Description","Debit","Credit"` + "\r\n" +
+		`"Cleared","08/02/2015","CAFÉ","4.50",""` + "\r\n"
+	entries, err := parseAll(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []qif.Entry{
+		{Date: date(2015, 8, 2), Amount: -450, Payee: "CAFÉ", Cleared: 1},
+	}
+	if !reflect.DeepEqual(entries, expect) {
+		t.Errorf("got\n%#v\nwant\n%#v", entries, expect)
+	}
+}
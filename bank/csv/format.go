@@ -0,0 +1,79 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+
+	"github.com/evmar/fin/bank"
+)
+
+func init() {
+	bank.Register(&bank.Format{
+		Name:        "csv",
+		Description: "Bank CSV exports, laid out as described by a profile",
+		Extensions:  []string{".csv"},
+		Sniff:       sniff,
+		Fallback:    true,
+		Open:        open,
+	})
+}
+
+// sniff accepts text whose first lines are records of more than one
+// field.  Most text with commas passes, hence the format is a
+// fallback.
+func sniff(prefix []byte) bool {
+	// The last line may be cut off.
+	if i := bytes.LastIndexByte(prefix, '\n'); i >= 0 {
+		prefix = prefix[:i+1]
+	}
+	r := csv.NewReader(bytes.NewReader(prefix))
+	r.FieldsPerRecord = -1
+	for i := 0; i < detectRows; i++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			return i > 1
+		}
+		if err != nil || len(row) < 2 {
+			return false
+		}
+	}
+	return true
+}
+
+func open(r io.Reader, opts *bank.Options) (bank.Reader, error) {
+	var profiles []*Profile
+	if opts.CSVProfiles != "" {
+		var err error
+		profiles, err = LoadProfiles(opts.CSVProfiles)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var cr *CSVReader
+	if opts.CSVProfile == "" {
+		var err error
+		cr, err = Detect(r, profiles)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		profile, err := FindProfile(opts.CSVProfile, profiles)
+		if err != nil {
+			return nil, err
+		}
+		cr, err = NewReader(r, profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.DateOrder != nil {
+		cr.DateOrder = *opts.DateOrder
+	}
+	return cr, nil
+}
+
+// Summary names the profile the input was read with.
+func (cr *CSVReader) Summary() []string {
+	return []string{"profile " + cr.profile.Name}
+}
@@ -0,0 +1,339 @@
+package csv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/evmar/fin/bank/money"
+	"github.com/evmar/fin/bank/qif"
+)
+
+// Column identifies a CSV column, either by its name in the header row
+// or by its zero-based index.  In JSON it is written as a string or a
+// number respectively.
+type Column struct {
+	Name  string
+	Index int
+}
+
+// Col returns a Column identified by name.
+func Col(name string) *Column {
+	return &Column{Name: name}
+}
+
+func (c *Column) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.Name); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &c.Index); err != nil {
+		return fmt.Errorf("column must be a name or an index, got %s", data)
+	}
+	return nil
+}
+
+func (c Column) MarshalJSON() ([]byte, error) {
+	if c.Name != "" {
+		return json.Marshal(c.Name)
+	}
+	return json.Marshal(c.Index)
+}
+
+func (c Column) String() string {
+	if c.Name != "" {
+		return strconv.Quote(c.Name)
+	}
+	return fmt.Sprintf("column %d", c.Index)
+}
+
+// Profile describes the layout of one bank's CSV export.
+type Profile struct {
+	// Name identifies the profile, e.g. "citi".
+	Name string `json:"name"`
+
+	// Skip is the number of junk rows before the header row.
+	Skip int `json:"skip,omitempty"`
+
+	// NoHeader is set if the file has no header row, in which case all
+	// columns must be given by index.
+	NoHeader bool `json:"noHeader,omitempty"`
+
+	// Date is the column holding the transaction date.
+	Date *Column `json:"date"`
+
+	// DateFormat is either a date order understood by dates.ParseOrder,
+	// like "mdy", or a Go time layout, like "2006-01-02T15:04:05".
+	// It defaults to "mdy".
+	DateFormat string `json:"dateFormat,omitempty"`
+
+	// Amount is the column holding a signed amount.  Alternatively,
+	// Debit and Credit are a pair of columns holding the money going
+	// out and coming in respectively, of which one is filled in.
+	Amount *Column `json:"amount,omitempty"`
+	Debit  *Column `json:"debit,omitempty"`
+	Credit *Column `json:"credit,omitempty"`
+
+	// Negate flips the sign of amounts, for exports where withdrawals
+	// are positive.  fin's convention is that withdrawals are negative.
+	Negate bool `json:"negate,omitempty"`
+
+	// Payee is the columns making up the payee.  Non-empty values are
+	// joined with ": ".
+	Payee []*Column `json:"payee"`
+
+	// Memo and Number are optional columns holding a memo and a
+	// transaction identifier.
+	Memo   *Column `json:"memo,omitempty"`
+	Number *Column `json:"number,omitempty"`
+
+	// Currency is an optional column holding the ISO 4217 code of the
+	// amount's currency.
+	Currency *Column `json:"currency,omitempty"`
+
+	// Filter, if set, skips rows unless the value in each named column
+	// is one of the listed values.
+	Filter map[string][]string `json:"filter,omitempty"`
+
+	// Signature is the header columns that identify this profile's
+	// files, for automatic detection.  A profile without a signature
+	// is only used when asked for by name.
+	Signature []string `json:"signature,omitempty"`
+
+	// sniff identifies files without a header row from their first
+	// row, for automatic detection.
+	sniff func(row []string) bool
+
+	// convert, if set, converts a row into entries in place of the
+	// generic conversion driven by the fields above.
+	convert func(cr *CSVReader, row []string) ([]*qif.Entry, error)
+}
+
+// Builtin holds the profiles that fin knows without configuration.
+var Builtin = []*Profile{
+	AmericanExpress,
+	AppleCard,
+	CapitalOne,
+	Chase,
+	ChaseChecking,
+	Citi,
+	Discover,
+	PayPal,
+	Venmo,
+	WellsFargo,
+}
+
+// AmericanExpress is the profile for American Express card exports,
+// which show charges as positive.
+var AmericanExpress = &Profile{
+	Name:      "amex",
+	Date:      Col("Date"),
+	Amount:    Col("Amount"),
+	Negate:    true,
+	Payee:     []*Column{Col("Description")},
+	Signature: []string{"Date", "Description", "Card Member", "Account #", "Amount"},
+}
+
+// AppleCard is the profile for Apple Card exports, which show
+// purchases as positive.
+var AppleCard = &Profile{
+	Name:      "applecard",
+	Date:      Col("Transaction Date"),
+	Amount:    Col("Amount (USD)"),
+	Negate:    true,
+	Payee:     []*Column{Col("Merchant")},
+	Memo:      Col("Description"),
+	Signature: []string{"Transaction Date", "Clearing Date", "Description", "Merchant", "Amount (USD)"},
+}
+
+// CapitalOne is the profile for Capital One card exports.
+var CapitalOne = &Profile{
+	Name:      "capitalone",
+	Date:      Col("Transaction Date"),
+	Debit:     Col("Debit"),
+	Credit:    Col("Credit"),
+	Payee:     []*Column{Col("Description")},
+	Signature: []string{"Transaction Date", "Posted Date", "Card No.", "Description", "Debit", "Credit"},
+}
+
+// Chase is the profile for Chase credit card exports.
+var Chase = &Profile{
+	Name:      "chase",
+	Date:      Col("Transaction Date"),
+	Amount:    Col("Amount"),
+	Payee:     []*Column{Col("Description")},
+	Memo:      Col("Memo"),
+	Signature: []string{"Transaction Date", "Post Date", "Description", "Category", "Type", "Amount"},
+}
+
+// ChaseChecking is the profile for Chase bank account exports.
+var ChaseChecking = &Profile{
+	Name:      "chase-checking",
+	Date:      Col("Posting Date"),
+	Amount:    Col("Amount"),
+	Payee:     []*Column{Col("Description")},
+	Number:    Col("Check or Slip #"),
+	Signature: []string{"Details", "Posting Date", "Description", "Amount", "Type", "Balance"},
+}
+
+// Citi is the profile for Citi credit card exports.  Citi's Debit
+// column holds charges and its Credit column holds payments.
+var Citi = &Profile{
+	Name:      "citi",
+	Date:      Col("Date"),
+	Debit:     Col("Debit"),
+	Credit:    Col("Credit"),
+	Payee:     []*Column{Col("Description")},
+	Signature: []string{"Status", "Date", "Description", "Debit", "Credit"},
+}
+
+// Discover is the profile for Discover card exports, which show
+// charges as positive.
+var Discover = &Profile{
+	Name:      "discover",
+	Date:      Col("Trans. Date"),
+	Amount:    Col("Amount"),
+	Negate:    true,
+	Payee:     []*Column{Col("Description")},
+	Signature: []string{"Trans. Date", "Post Date", "Description", "Amount"},
+}
+
+// PayPal is the profile for PayPal activity downloads.  The net amount
+// is used, so fees are already deducted.
+var PayPal = &Profile{
+	Name:      "paypal",
+	Date:      Col("Date"),
+	Amount:    Col("Net"),
+	Payee:     []*Column{Col("Name")},
+	Memo:      Col("Type"),
+	Number:    Col("Transaction ID"),
+	Currency:  Col("Currency"),
+	Filter:    map[string][]string{"Status": {"Completed"}},
+	Signature: []string{"Date", "Time", "Name", "Type", "Status", "Gross", "Fee", "Net", "Transaction ID"},
+}
+
+// Venmo is the profile for Venmo account statements.  Venmo rows need
+// more interpretation than a profile can express; see venmoEntries.
+var Venmo = &Profile{
+	Name:       "venmo",
+	Skip:       2,
+	Date:       Col("Datetime"),
+	DateFormat: "2006-01-02T15:04:05",
+	Amount:     Col("Amount (total)"),
+	Payee:      []*Column{Col("From"), Col("To")},
+	Memo:       Col("Note"),
+	Number:     Col("ID"),
+	Signature:  []string{"ID", "Datetime", "Type", "Status", "Note", "From", "To", "Amount (total)"},
+	convert:    venmoEntries,
+}
+
+// WellsFargo is the profile for Wells Fargo exports, which have no
+// header row.  Their rows look like:
+//
+//	"01/31/2024","-12.34","*","","DESCRIPTION"
+var WellsFargo = &Profile{
+	Name:     "wellsfargo",
+	NoHeader: true,
+	Date:     &Column{Index: 0},
+	Amount:   &Column{Index: 1},
+	Payee:    []*Column{{Index: 4}},
+	sniff: func(row []string) bool {
+		if len(row) != 5 || row[2] != "*" {
+			return false
+		}
+		_, err := money.Parse(row[1])
+		return err == nil
+	},
+}
+
+// LoadProfiles reads a JSON file holding a list of profiles.
+func LoadProfiles(path string) ([]*Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var profiles []*Profile
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&profiles); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, p := range profiles {
+		if err := p.check(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return profiles, nil
+}
+
+// FindProfile returns the profile with the given name from profiles,
+// or else from the built-in profiles.
+func FindProfile(name string, profiles []*Profile) (*Profile, error) {
+	for _, list := range [][]*Profile{profiles, Builtin} {
+		for _, p := range list {
+			if p.Name == name {
+				return p, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no CSV profile named %q", name)
+}
+
+// matches reports whether row is the header row (or for profiles with
+// no header, the first row) of a file for this profile, and if so how
+// specific the match is.
+func (p *Profile) matches(row []string) (bool, int) {
+	if p.sniff != nil {
+		return p.sniff(row), 1
+	}
+	if len(p.Signature) == 0 {
+		return false, 0
+	}
+	have := map[string]bool{}
+	for _, name := range row {
+		have[strings.TrimSpace(name)] = true
+	}
+	for _, name := range p.Signature {
+		if !have[name] {
+			return false, 0
+		}
+	}
+	return true, len(p.Signature)
+}
+
+func (p *Profile) check() error {
+	if p.Name == "" {
+		return fmt.Errorf("CSV profile missing name")
+	}
+	if p.Date == nil {
+		return fmt.Errorf("CSV profile %q: missing date column", p.Name)
+	}
+	if (p.Amount == nil) == (p.Debit == nil && p.Credit == nil) {
+		return fmt.Errorf("CSV profile %q: need either an amount column or debit/credit columns", p.Name)
+	}
+	if p.NoHeader {
+		for _, c := range p.columns() {
+			if c.Name != "" {
+				return fmt.Errorf("CSV profile %q: column %s must be an index with noHeader", p.Name, c)
+			}
+		}
+		if len(p.Filter) > 0 {
+			return fmt.Errorf("CSV profile %q: filter needs a header row", p.Name)
+		}
+	}
+	return nil
+}
+
+// columns returns all the columns that the profile refers to.
+func (p *Profile) columns() []*Column {
+	cols := []*Column{p.Date}
+	for _, c := range []*Column{p.Amount, p.Debit, p.Credit, p.Memo, p.Number, p.Currency} {
+		if c != nil {
+			cols = append(cols, c)
+		}
+	}
+	return append(cols, p.Payee...)
+}
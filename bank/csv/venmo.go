@@ -0,0 +1,105 @@
+package csv
+
+import (
+	"strings"
+
+	"github.com/evmar/fin/bank/money"
+	"github.com/evmar/fin/bank/qif"
+)
+
+// venmoSkipStatus are the statuses of Venmo transactions that didn't
+// (or didn't yet) move money.
+var venmoSkipStatus = map[string]bool{
+	"Pending":   true,
+	"Canceled":  true,
+	"Cancelled": true,
+	"Denied":    true,
+	"Failed":    true,
+}
+
+// venmoEntries converts a row of a Venmo statement.
+//
+// The payee is the counterparty, which depends on who initiated the
+// transaction: in a payment From pays To, while in a charge From asks
+// To for money.  Transfers to and from the bank have the bank account
+// as their counterparty, so that they can be matched against the
+// bank's side of the transfer.  From and To are kept as they are.
+//
+// Amount (total) is what left or reached the Venmo balance, including
+// any fee.  The fee becomes a separate entry, so the transaction itself
+// is booked as the total less the fee: a $100.00 instant transfer with
+// a $1.75 fee moves $98.25 to the bank.
+func venmoEntries(cr *CSVReader, row []string) ([]*qif.Entry, error) {
+	get := func(name string) string {
+		return cr.get(row, Col(name))
+	}
+	if venmoSkipStatus[get("Status")] {
+		return nil, nil
+	}
+
+	e := &qif.Entry{Cleared: qif.Cleared}
+	var err error
+	e.Date, err = cr.parseDate(get("Datetime"))
+	if err != nil {
+		return nil, err
+	}
+	e.Amount, err = money.Parse(get("Amount (total)"))
+	if err != nil {
+		return nil, err
+	}
+	e.Number = get("ID")
+	e.Type = get("Type")
+	e.Memo = get("Note")
+
+	from, to := get("From"), get("To")
+	e.From, e.To = from, to
+	outgoing := e.Amount < 0
+	switch t := e.Type; {
+	case t == "Charge":
+		e.Payee = from
+		if !outgoing {
+			e.Payee = to
+		}
+	case strings.Contains(t, "Transfer"):
+		e.Payee = get("Destination")
+		if e.Payee == "" {
+			e.Payee = get("Funding Source")
+		}
+		if e.Memo == "" {
+			e.Memo = t
+		}
+	default:
+		// Payments, merchant transactions, and anything else.
+		e.Payee = to
+		if !outgoing {
+			e.Payee = from
+		}
+	}
+	if e.Payee == "" {
+		e.Payee = e.Type
+	}
+	entries := []*qif.Entry{e}
+
+	if fee := get("Amount (fee)"); fee != "" {
+		amount, err := money.Parse(fee)
+		if err != nil {
+			return nil, err
+		}
+		if amount != 0 {
+			if amount > 0 {
+				amount = -amount
+			}
+			e.Amount -= amount
+			entries = append(entries, &qif.Entry{
+				Date:    e.Date,
+				Amount:  amount,
+				Payee:   "Venmo",
+				Memo:    e.Type + " fee",
+				Number:  e.Number + "-fee",
+				Type:    "Fee",
+				Cleared: qif.Cleared,
+			})
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dates parses the dates found in bank statements, which come
+// in many conventions: "01/02/2006", "02.01.2006", "2006-01-02",
+// "20060102", Quicken's "1/ 2'06", "2 Jan 2006", and so on.
+package dates
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Order is the order of the day, month, and year in a date.  It only
+// matters for dates like "01/02/2006" where the fields can't be told
+// apart otherwise.
+type Order int
+
+const (
+	// Auto infers the order from each date, and fails with
+	// ErrAmbiguous for dates like "01/02/2006".
+	Auto Order = iota
+	// MDY is the US order, e.g. "01/31/2006".
+	MDY
+	// DMY is the European order, e.g. "31/01/2006".
+	DMY
+	// YMD is the ISO order, e.g. "2006-01-31".
+	YMD
+)
+
+var orderNames = []string{"auto", "mdy", "dmy", "ymd"}
+
+func (o Order) String() string {
+	if int(o) < len(orderNames) {
+		return orderNames[o]
+	}
+	return fmt.Sprintf("Order(%d)", int(o))
+}
+
+// ParseOrder parses the name of an Order, e.g. "dmy".
+func ParseOrder(name string) (Order, error) {
+	for i, n := range orderNames {
+		if strings.EqualFold(name, n) {
+			return Order(i), nil
+		}
+	}
+	return Auto, fmt.Errorf("unknown date order %q, want one of %s", name, strings.Join(orderNames, ", "))
+}
+
+// ErrAmbiguous is returned for a date whose day and month can't be
+// told apart, e.g. "01/02/2006" with the Auto order.
+var ErrAmbiguous = errors.New("ambiguous date")
+
+var months = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+// field is one component of a date.
+type field struct {
+	text  string
+	num   int
+	month bool // text is a month name
+}
+
+func split(s string) ([]field, error) {
+	var fields []field
+	for _, text := range strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune("/-.,' ", r)
+	}) {
+		f := field{text: text}
+		if n, err := strconv.Atoi(text); err == nil {
+			f.num = n
+		} else if len(text) >= 3 {
+			for i, m := range months {
+				if strings.EqualFold(text[:3], m) {
+					f.num = i + 1
+					f.month = true
+				}
+			}
+			if !f.month {
+				return nil, fmt.Errorf("bad date %q", s)
+			}
+		} else {
+			return nil, fmt.Errorf("bad date %q", s)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// year expands a two-digit year.  Quicken writes years after 1999 with
+// an apostrophe, as in "1/ 2'06"; otherwise two-digit years are
+// assumed to be within 1970-2069.
+func year(f field, apostrophe bool) int {
+	if len(f.text) > 2 {
+		return f.num
+	}
+	if apostrophe || f.num < 70 {
+		return 2000 + f.num
+	}
+	return 1900 + f.num
+}
+
+// Parse parses a date, using order to resolve dates whose field order
+// isn't evident from the date itself.
+func Parse(s string, order Order) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 8 && strings.Trim(s, "0123456789") == "" {
+		// Compact ISO, e.g. "20060102".
+		y, _ := strconv.Atoi(s[0:4])
+		m, _ := strconv.Atoi(s[4:6])
+		d, _ := strconv.Atoi(s[6:8])
+		return makeDate(s, y, m, d)
+	}
+	fields, err := split(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(fields) != 3 {
+		return time.Time{}, fmt.Errorf("bad date %q", s)
+	}
+	a, b, c := fields[0], fields[1], fields[2]
+	apostrophe := strings.Contains(s, "'")
+
+	switch {
+	case a.month:
+		// "Jan 2, 2006".
+		return makeDate(s, year(c, apostrophe), a.num, b.num)
+	case b.month:
+		// "2 Jan 2006" or "2006 Jan 02".
+		if len(a.text) == 4 {
+			return makeDate(s, a.num, b.num, c.num)
+		}
+		return makeDate(s, year(c, apostrophe), b.num, a.num)
+	case len(a.text) == 4 || order == YMD:
+		return makeDate(s, year(a, false), b.num, c.num)
+	}
+
+	y := year(c, apostrophe)
+	switch order {
+	case MDY:
+		return makeDate(s, y, a.num, b.num)
+	case DMY:
+		return makeDate(s, y, b.num, a.num)
+	}
+	switch {
+	case a.num > 12 || a.num == b.num:
+		return makeDate(s, y, b.num, a.num)
+	case b.num > 12:
+		return makeDate(s, y, a.num, b.num)
+	}
+	return time.Time{}, fmt.Errorf("%w %q: could be day/month or month/day", ErrAmbiguous, s)
+}
+
+func makeDate(s string, y, m, d int) (time.Time, error) {
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if t.Year() != y || t.Month() != time.Month(m) || t.Day() != d {
+		return time.Time{}, fmt.Errorf("bad date %q", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dates
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in    string
+		order Order
+		want  time.Time
+	}{
+		{"01/02/2006", MDY, date(2006, 1, 2)},
+		{"01/02/2006", DMY, date(2006, 2, 1)},
+		{"1/ 2'03", MDY, date(2003, 1, 2)},
+		{"1/ 2/98", MDY, date(1998, 1, 2)},
+		{"31.01.2006", Auto, date(2006, 1, 31)},
+		{"01/31/2006", Auto, date(2006, 1, 31)},
+		{"05/05/2006", Auto, date(2006, 5, 5)},
+		{"2006-01-31", Auto, date(2006, 1, 31)},
+		{"2006-01-31", DMY, date(2006, 1, 31)},
+		{"20060131", MDY, date(2006, 1, 31)},
+		{"31 Jan 2006", Auto, date(2006, 1, 31)},
+		{"Jan 31, 2006", Auto, date(2006, 1, 31)},
+		{"31-JAN-06", Auto, date(2006, 1, 31)},
+		{" 1/31/2006 ", MDY, date(2006, 1, 31)},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.in, test.order)
+		if err != nil {
+			t.Errorf("Parse(%q, %v): %v", test.in, test.order, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("Parse(%q, %v) = %v, want %v", test.in, test.order, got, test.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := Parse("01/02/2006", Auto); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("expected ambiguous, got %v", err)
+	}
+	for _, in := range []string{"", "13/13/2006", "02/30/2006", "01/02", "foo", "Foo 2, 2006"} {
+		if _, err := Parse(in, MDY); err == nil {
+			t.Errorf("Parse(%q): expected error", in)
+		}
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	if o, err := ParseOrder("DMY"); err != nil || o != DMY {
+		t.Errorf("ParseOrder: got %v, %v", o, err)
+	}
+	if _, err := ParseOrder("dym"); err == nil {
+		t.Errorf("ParseOrder: expected error")
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bank
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/dates"
+)
+
+// Reader reads the entries of a file, returning io.EOF after the last.
+type Reader interface {
+	ReadEntry() (*Entry, error)
+}
+
+// Summarizer is implemented by readers with more to say about their
+// input than its entries, such as a statement's balances.  Summary is
+// called once all the entries are read, and returns lines to show.
+type Summarizer interface {
+	Summary() []string
+}
+
+// Options are settings for reading a file.  Each format ignores the
+// options that don't apply to it.
+type Options struct {
+	// DateOrder, if set, overrides the format's usual order of date
+	// fields, e.g. for a bank that exports European dates.
+	DateOrder *dates.Order
+
+	// Account selects the account to read from a file, such as a
+	// journal, that records several.
+	Account string
+
+	// CSVProfiles is the path to a JSON file of CSV profiles, and
+	// CSVProfile the name of the profile to read CSV files with.
+	CSVProfiles string
+	CSVProfile  string
+}
+
+// Format is a file format that entries can be read from.
+type Format struct {
+	// Name identifies the format, e.g. for choosing it explicitly.
+	// Sample value: "qif".
+	Name string
+
+	// Description describes the format to users.
+	Description string
+
+	// Extensions are the file extensions, with the dot and in lower
+	// case, that the format's files usually have.
+	Extensions []string
+
+	// Sniff reports whether text starting with prefix looks like the
+	// format.  The prefix is already decoded to UTF-8.
+	Sniff func(prefix []byte) bool
+
+	// Fallback marks a format whose Sniff accepts most text, such as
+	// CSV, so that it's only chosen when no other format matches.
+	Fallback bool
+
+	// CategoryTags marks a format whose categories are the accounts
+	// the entries were kept with, such as a journal's, so that they
+	// always become tags rather than only with -category-tags.
+	CategoryTags bool
+
+	// Open returns a reader of the entries in r.
+	Open func(r io.Reader, opts *Options) (Reader, error)
+}
+
+var formats = map[string]*Format{}
+
+// Register makes a format available.  It is meant to be called from
+// the init function of the package that reads the format.
+func Register(f *Format) {
+	if _, ok := formats[f.Name]; ok {
+		panic(fmt.Sprintf("bank: format %q registered twice", f.Name))
+	}
+	formats[f.Name] = f
+}
+
+// Formats returns the registered formats, ordered by name.
+func Formats() []*Format {
+	var fs []*Format
+	for _, f := range formats {
+		fs = append(fs, f)
+	}
+	sort.Slice(fs, func(i, j int) bool { return fs[i].Name < fs[j].Name })
+	return fs
+}
+
+// Lookup returns the format with the given name, or nil.
+func Lookup(name string) *Format {
+	return formats[strings.ToLower(name)]
+}
+
+// ForPath returns the format that files with path's extension have, or
+// nil if no format, or more than one, claims the extension.
+func ForPath(path string) *Format {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return nil
+	}
+	var found *Format
+	for _, f := range Formats() {
+		for _, e := range f.Extensions {
+			if e == ext {
+				if found != nil {
+					return nil
+				}
+				found = f
+			}
+		}
+	}
+	return found
+}
+
+// SniffLen is how much of the start of a file Detect examines.
+const SniffLen = 4096
+
+// Detect returns the format of text starting with prefix, or nil if
+// it matches none.  The prefix may be in any charset that package
+// charset detects, and only its first SniffLen bytes are examined.
+func Detect(prefix []byte) *Format {
+	if len(prefix) > SniffLen {
+		prefix = prefix[:SniffLen]
+	}
+	text, err := io.ReadAll(charset.NewReader(bytes.NewReader(prefix), ""))
+	if err != nil {
+		return nil
+	}
+	var fallback *Format
+	for _, f := range Formats() {
+		if f.Sniff == nil || !f.Sniff(text) {
+			continue
+		}
+		if !f.Fallback {
+			return f
+		}
+		if fallback == nil {
+			fallback = f
+		}
+	}
+	return fallback
+}
+
+// Identify returns the format of a file with the given name whose
+// content starts with prefix, or nil if it's unknown.  Banks name
+// their downloads carelessly, so the content counts for more than the
+// extension: a format recognized by its content wins, then the one
+// the extension implies, then a Fallback format that the content
+// matched.
+func Identify(name string, prefix []byte) *Format {
+	detected := Detect(prefix)
+	if detected != nil && !detected.Fallback {
+		return detected
+	}
+	if f := ForPath(name); f != nil {
+		return f
+	}
+	return detected
+}
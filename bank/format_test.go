@@ -0,0 +1,101 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/evmar/fin/bank"
+	_ "github.com/evmar/fin/bank/all"
+)
+
+func TestForPath(t *testing.T) {
+	tests := []struct {
+		path, format string
+	}{
+		{"checking.qif", "qif"},
+		{"dl/Export.CSV", "csv"},
+		{"stmt.QFX", "ofx"},
+		{"camt053.xml", "camt"},
+		{"konto.sta", "mt940"},
+		{"books.beancount", "journal"},
+		{"download", ""},
+		{"export.txt", ""},
+	}
+	for _, test := range tests {
+		name := ""
+		if f := bank.ForPath(test.path); f != nil {
+			name = f.Name
+		}
+		if name != test.format {
+			t.Errorf("%s: got format %q, want %q", test.path, name, test.format)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		input, format string
+	}{
+		{"!Type:Bank\nD01/02/2013\nT-4.50\n^\n", "qif"},
+		{"\xef\xbb\xbf!Type:CCard\n", "qif"},
+		{"!TYPE:BANK\nD01/02/2013\nT-4.50\n^\n", "qif"},
+		{"OFXHEADER:100\nDATA:OFXSGML\nVERSION:102\n\n<OFX>\n", "ofx"},
+		{"<?xml version=\"1.0\"?>\n<?OFX OFXHEADER=\"200\" VERSION=\"211\"?>\n<OFX>\n", "ofx"},
+		{"<?xml version=\"1.0\"?>\n<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02\">\n", "camt"},
+		{":20:STARTUMSE\n:25:10020030/1234567\n:28C:00001/001\n", "mt940"},
+		{"{1:F01BANKDEFFXXXX0000000000}{2:I940BANKDEFFXXXXN}{4:\n:20:X\n", "mt940"},
+		{"2024-01-02 * \"Cafe\"\n  Assets:Checking  -4.50 USD\n  Expenses:Food\n", "journal"},
+		{"2024/01/02 Cafe\n    Expenses:Food    $4.50\n    Assets:Checking\n", "journal"},
+		{"Date,Description,Amount\n01/02/2024,Cafe,-4.50\n", "csv"},
+		{"\xff\xfeD\x00,\x00A\x00\n\x001\x00,\x002\x00\n\x00", "csv"},
+		{"Dear customer,\n", ""},
+	}
+	for _, test := range tests {
+		name := ""
+		if f := bank.Detect([]byte(test.input)); f != nil {
+			name = f.Name
+		}
+		if name != test.format {
+			t.Errorf("%q: got format %q, want %q", test.input, name, test.format)
+		}
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	const qif = "!Type:Bank\nD01/02/2013\nT-4.50\n^\n"
+	const csv = "Date,Description,Amount\n01/02/2024,Cafe,-4.50\n"
+	tests := []struct {
+		name, input, format string
+	}{
+		{"download", qif, "qif"},
+		{"export.txt", csv, "csv"},
+		{"Statement.CSV", csv, "csv"},
+		// The content wins over a misleading extension...
+		{"export.csv", qif, "qif"},
+		// ...but a format that accepts most text doesn't.
+		{"books.ledger", "2024/01/02,Cafe\n2024/01/03,Bar\n", "journal"},
+		{"-", "garbage", ""},
+	}
+	for _, test := range tests {
+		name := ""
+		if f := bank.Identify(test.name, []byte(test.input)); f != nil {
+			name = f.Name
+		}
+		if name != test.format {
+			t.Errorf("%s: got format %q, want %q", test.name, name, test.format)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"errors"
+	"io"
+	"regexp"
+
+	"github.com/evmar/fin/bank"
+)
+
+func init() {
+	bank.Register(&bank.Format{
+		Name:         "journal",
+		Description:  "Beancount and Ledger journals, one account at a time",
+		Extensions:   []string{".beancount", ".bean", ".ledger", ".journal"},
+		Sniff:        sniff,
+		CategoryTags: true,
+		Open:         open,
+	})
+}
+
+// transactionRE matches a dated line followed by an indented posting
+// (or Beancount metadata), and openRE a Beancount open directive.
+var (
+	transactionRE = regexp.MustCompile(`(?m)^\d{4}[-/]\d{1,2}[-/]\d{1,2}\b.*\n[ \t]+[A-Za-z][^\s:]*:`)
+	openRE        = regexp.MustCompile(`(?m)^\d{4}-\d\d-\d\d open `)
+)
+
+func sniff(prefix []byte) bool {
+	return transactionRE.Match(prefix) || openRE.Match(prefix)
+}
+
+func open(r io.Reader, opts *bank.Options) (bank.Reader, error) {
+	if opts.Account == "" {
+		return nil, errors.New("reading a journal needs the account to read")
+	}
+	return NewReader(r, opts.Account), nil
+}
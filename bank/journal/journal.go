@@ -0,0 +1,561 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package journal reads plain-text accounting journals in the
+// Beancount and Ledger formats.
+package journal
+
+// A journal holds transactions like
+//
+//	2024-01-05 * "Cafe" "Lunch"                  (Beancount)
+//	  Expenses:Food:Restaurants   12.50 USD
+//	  Assets:Checking
+//
+//	2024/01/05 * (1001) Cafe  ; Lunch            (Ledger)
+//	    Expenses:Food:Restaurants    $12.50
+//	    Assets:Checking
+//
+// Each transaction has postings to two or more accounts, which sum to
+// zero, valuing a posting with a cost or price at that; at most one
+// may leave out its amount.  A Reader turns each posting to one chosen
+// account, such as a bank account, into an entry.  Other directives,
+// such as Beancount's "open" and Ledger's "P", are skipped.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/money"
+	"github.com/evmar/fin/bank/qif"
+)
+
+// posting is one leg of a transaction.
+type posting struct {
+	account string
+	// amount is the posting's amount in cents, valid if hasAmount.
+	amount    int
+	hasAmount bool
+	commodity string
+	// weight is what the posting adds to the balance of the
+	// transaction, in cents of weightCommodity: its amount, or its
+	// cost or price if it has one.  It's nil if unknown.
+	weight          *big.Rat
+	weightCommodity string
+	// amountText is the posting's amount as written, if any, and
+	// priceText the cost or price after it, e.g. "@ 1.10 USD".
+	amountText string
+	priceText  string
+}
+
+// transaction is a journal transaction as read.
+type transaction struct {
+	line     int
+	date     time.Time
+	cleared  qif.ClearedType
+	code     string
+	payee    string
+	memo     string
+	postings []*posting
+}
+
+type Reader struct {
+	// account is the account whose postings become entries.
+	account string
+
+	s       *bufio.Scanner
+	lineNum int
+	// pending is a line that was read ahead while looking for the end
+	// of a transaction.
+	pending *string
+
+	// accounts holds all accounts seen, for the error when none
+	// matches.
+	accounts map[string]bool
+	matched  bool
+
+	queued []*qif.Entry
+}
+
+// NewReader returns a reader of the postings to account in a journal.
+// Sample account: "Assets:Bank:Checking".
+func NewReader(r io.Reader, account string) *Reader {
+	return &Reader{
+		account:  account,
+		s:        bufio.NewScanner(charset.NewReader(r, "")),
+		accounts: map[string]bool{},
+	}
+}
+
+func (r *Reader) errorf(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (r *Reader) line() (string, bool) {
+	if l := r.pending; l != nil {
+		r.pending = nil
+		return *l, true
+	}
+	if !r.s.Scan() {
+		return "", false
+	}
+	r.lineNum++
+	return strings.TrimRight(r.s.Text(), " \t\r"), true
+}
+
+func isIndented(l string) bool {
+	return l != "" && (l[0] == ' ' || l[0] == '\t')
+}
+
+// headerRE matches the start of a transaction: the date, an optional
+// Ledger auxiliary date, and the rest.
+var headerRE = regexp.MustCompile(`^(\d{4}[-/.]\d{1,2}[-/.]\d{1,2})(?:=\S+)?(?:\s+(.*))?$`)
+
+// parseDate parses a journal date, e.g. "2024-01-05" or "2024/1/5".
+func parseDate(s string) (time.Time, error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '/' || r == '.' })
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("bad date %q", s)
+	}
+	return time.Parse("2006-1-2", strings.Join(parts, "-"))
+}
+
+// cutComment splits a line at a ';' comment.
+func cutComment(s string) (string, string) {
+	before, after, _ := strings.Cut(s, ";")
+	return strings.TrimSpace(before), strings.TrimSpace(after)
+}
+
+// beancountStrings parses the quoted payee and narration of a
+// Beancount transaction header, ignoring any #tags and ^links.
+func beancountStrings(s string) ([]string, bool) {
+	var strs []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" || s[0] == '#' || s[0] == '^' || s[0] == ';' {
+			return strs, true
+		}
+		if s[0] != '"' {
+			return nil, false
+		}
+		str, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return nil, false
+		}
+		unq, err := strconv.Unquote(str)
+		if err != nil {
+			return nil, false
+		}
+		strs = append(strs, unq)
+		s = s[len(str):]
+	}
+}
+
+// header parses a transaction header after the date.  It returns false
+// for directives that aren't transactions.
+func (t *transaction) header(rest string) bool {
+	flag, after, _ := strings.Cut(rest, " ")
+	switch flag {
+	case "*":
+		t.cleared = qif.Cleared
+	case "!", "txn":
+	default:
+		flag, after = "", rest
+	}
+
+	// Beancount: one or two quoted strings.
+	if strs, ok := beancountStrings(after); ok && len(strs) > 0 {
+		if len(strs) == 1 {
+			t.payee = strs[0]
+		} else {
+			t.payee, t.memo = strs[0], strs[1]
+		}
+		return true
+	}
+	if flag == "txn" {
+		return true
+	}
+	// Other Beancount directives ("open", "balance", ...) have no flag.
+	if word, _, _ := strings.Cut(after, " "); flag == "" && beancountDirectives[word] {
+		return false
+	}
+
+	// Ledger: an optional (code), then the payee and a comment.
+	after = strings.TrimSpace(after)
+	if strings.HasPrefix(after, "(") {
+		if i := strings.Index(after, ")"); i > 0 {
+			t.code = after[1:i]
+			after = after[i+1:]
+		}
+	}
+	t.payee, t.memo = cutComment(after)
+	return true
+}
+
+var beancountDirectives = map[string]bool{
+	"open": true, "close": true, "commodity": true, "balance": true, "pad": true,
+	"note": true, "document": true, "price": true, "event": true, "query": true,
+	"custom": true,
+}
+
+// amountRE matches an amount with its commodity before or after the
+// number, e.g. "-12.50 USD", "$-12.50", "-$1,234.50".
+var amountRE = regexp.MustCompile(`^(-?)\s*([^\d\s.,+-]*)\s*(-?[\d.,]+)\s*([^\d\s.,+-]*)$`)
+
+// currencies maps commodity symbols to currency codes.
+var currencies = map[string]string{"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY"}
+
+// splitAmount splits an amount into its number, with any sign, and its
+// commodity.  Commas in the number are thousands separators, and are
+// dropped.
+func splitAmount(s string) (string, string, error) {
+	m := amountRE.FindStringSubmatch(s)
+	if m == nil || (m[2] != "" && m[4] != "") {
+		return "", "", fmt.Errorf("bad amount %q", s)
+	}
+	commodity := m[2] + m[4]
+	if c, ok := currencies[commodity]; ok {
+		commodity = c
+	}
+	num := strings.ReplaceAll(m[3], ",", "")
+	if m[1] == "-" {
+		if strings.HasPrefix(num, "-") {
+			num = num[1:]
+		} else {
+			num = "-" + num
+		}
+	}
+	return num, commodity, nil
+}
+
+// parseQuantity parses an amount that needn't be in whole cents, such
+// as a number of shares.
+func parseQuantity(s string) (*big.Rat, string, error) {
+	num, commodity, err := splitAmount(s)
+	if err != nil {
+		return nil, "", err
+	}
+	q, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return nil, "", fmt.Errorf("bad amount %q", s)
+	}
+	return q, commodity, nil
+}
+
+// weigh computes a posting's weight.  A price "@ 1.10 USD" or cost
+// "{1.10 USD}" is per unit of the amount, and "@@ 110 USD" or
+// "{{110 USD}}" for all of it.  A cost takes precedence over a price,
+// as in Beancount and Ledger.
+func (p *posting) weigh() error {
+	qty, commodity, err := parseQuantity(p.amountText)
+	if err != nil {
+		return err
+	}
+	price, total := "", false
+	switch text := p.priceText; {
+	case text == "":
+		p.weight = new(big.Rat).Mul(qty, big.NewRat(100, 1))
+		p.weightCommodity = commodity
+		return nil
+	case strings.HasPrefix(text, "{{"):
+		price, _, _ = strings.Cut(text[2:], "}}")
+		total = true
+	case strings.HasPrefix(text, "{"):
+		price, _, _ = strings.Cut(text[1:], "}")
+		// Beancount may add a date and label: {1.10 USD, 2024-01-05}.
+		price, _, _ = strings.Cut(price, ", ")
+		if strings.Contains(price, "#") {
+			return fmt.Errorf("unsupported cost %q", text)
+		}
+	case strings.HasPrefix(text, "@@"):
+		price, total = text[2:], true
+	case strings.HasPrefix(text, "@"):
+		price = text[1:]
+	}
+	price = strings.TrimSpace(price)
+	if price == "" {
+		return fmt.Errorf("%q has no cost", p.amountText+" "+p.priceText)
+	}
+	unit, unitCommodity, err := parseQuantity(price)
+	if err != nil {
+		return err
+	}
+	w := new(big.Rat)
+	if total {
+		w.Abs(unit)
+		if qty.Sign() < 0 {
+			w.Neg(w)
+		}
+	} else {
+		w.Mul(qty, unit)
+	}
+	p.weight = w.Mul(w, big.NewRat(100, 1))
+	p.weightCommodity = unitCommodity
+	return nil
+}
+
+// parsePosting parses an indented posting line, e.g.
+// "  Expenses:Food   12.50 USD @ 1.1 EUR ; note".
+func parsePosting(l string) *posting {
+	l, _ = cutComment(l)
+	// A posting may carry its own flag.
+	if len(l) > 2 && (l[0] == '*' || l[0] == '!') && l[1] == ' ' {
+		l = strings.TrimSpace(l[2:])
+	}
+	// The account ends at a tab or two spaces, since account names
+	// may contain single spaces in Ledger.
+	account, amount := l, ""
+	if i := strings.IndexAny(l, "\t"); i >= 0 {
+		account, amount = l[:i], l[i:]
+	}
+	if i := strings.Index(account, "  "); i >= 0 {
+		account, amount = l[:i], l[i:]
+	}
+	// Beancount allows a single space too, but its account names have
+	// no spaces, so split there only if what follows is an amount.
+	if amount == "" {
+		if i := strings.Index(account, " "); i >= 0 && amountRE.MatchString(strings.TrimSpace(dropPrice(l[i:]))) {
+			account, amount = l[:i], l[i:]
+		}
+	}
+	// Ledger virtual postings are in parentheses or brackets.
+	account = strings.Trim(strings.TrimSpace(account), "()[]")
+	// A Ledger balance assertion "= 100 USD" doesn't affect the
+	// posting.
+	amount, _, _ = strings.Cut(amount, "=")
+	price := ""
+	if i := strings.IndexAny(amount, "{@"); i >= 0 {
+		amount, price = amount[:i], amount[i:]
+	}
+	return &posting{account: account, amountText: strings.TrimSpace(amount), priceText: strings.TrimSpace(price)}
+}
+
+// dropPrice drops any cost, price or balance assertion following an
+// amount.
+func dropPrice(amount string) string {
+	if i := strings.IndexAny(amount, "{@="); i >= 0 {
+		return amount[:i]
+	}
+	return amount
+}
+
+// metadataRE matches a line of Beancount metadata, e.g. `id: "123"`.
+var metadataRE = regexp.MustCompile(`^[a-z][\w-]*:(\s|$)`)
+
+// read reads the next transaction, or returns nil at the end of the
+// input.
+func (r *Reader) read() (*transaction, error) {
+	for {
+		l, ok := r.line()
+		if !ok {
+			return nil, r.s.Err()
+		}
+		m := headerRE.FindStringSubmatch(l)
+		if m == nil {
+			// Comments, Ledger directives, and the bodies of skipped
+			// directives.
+			continue
+		}
+		t := &transaction{line: r.lineNum}
+		date, err := parseDate(m[1])
+		if err != nil {
+			return nil, r.errorf(r.lineNum, "%v", err)
+		}
+		t.date = date
+		if !t.header(m[2]) {
+			continue
+		}
+		for {
+			l, ok := r.line()
+			if !ok {
+				break
+			}
+			if !isIndented(l) {
+				r.pending = &l
+				break
+			}
+			l = strings.TrimSpace(l)
+			switch {
+			case strings.HasPrefix(l, ";"):
+				// A Ledger note on the transaction.
+				if note := strings.TrimSpace(l[1:]); t.memo == "" {
+					t.memo = note
+				}
+				continue
+			case metadataRE.MatchString(l):
+				// Beancount metadata, e.g. `  id: "123"`.
+				continue
+			}
+			t.postings = append(t.postings, parsePosting(l))
+		}
+		if err := r.amounts(t); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+}
+
+// amounts parses the postings' amounts, filling in an elided one from
+// the weights of the others.  The elided amount is left unknown if
+// any other posting's weight is unknown, or the weights are in more
+// than one commodity or don't sum to whole cents.
+func (r *Reader) amounts(t *transaction) error {
+	var elided *posting
+	sum := new(big.Rat)
+	commodity := ""
+	balanced := true
+	for _, p := range t.postings {
+		r.accounts[p.account] = true
+		if p.amountText == "" {
+			if elided != nil {
+				return r.errorf(t.line, "more than one posting without an amount")
+			}
+			elided = p
+			continue
+		}
+		num, c, err := splitAmount(p.amountText)
+		if err == nil {
+			p.amount, err = money.ParseDecimal(num)
+		}
+		if err == nil {
+			p.commodity, p.hasAmount = c, true
+		} else if p.account == r.account {
+			return r.errorf(t.line, "%s: %v", p.account, err)
+		}
+		// Other legs may be in units fin can't represent, such as
+		// fractional shares, but still have a weight.
+		if err := p.weigh(); err != nil {
+			balanced = false
+			continue
+		}
+		if commodity != "" && p.weightCommodity != commodity {
+			balanced = false
+		}
+		commodity = p.weightCommodity
+		sum.Add(sum, p.weight)
+	}
+	if elided != nil && balanced && sum.IsInt() {
+		n := new(big.Rat).Neg(sum)
+		elided.amount, elided.commodity, elided.hasAmount = int(n.Num().Int64()), commodity, true
+		elided.weight, elided.weightCommodity = n, commodity
+	}
+	return nil
+}
+
+// Category maps a journal account to the category of an entry.  The
+// root of income and expense accounts is dropped, so
+// "Expenses:Food:Restaurants" becomes "Food:Restaurants".  Other
+// accounts are transfers, written in brackets as in QIF:
+// "[Assets:Savings]".
+func Category(account string) string {
+	root, rest, _ := strings.Cut(account, ":")
+	switch root {
+	case "Expenses", "Income", "Revenue", "Revenues":
+		if rest != "" {
+			return rest
+		}
+		return root
+	case "Assets", "Liabilities", "Equity":
+		return "[" + account + "]"
+	}
+	return account
+}
+
+// entries converts the postings to the chosen account into entries.
+func (r *Reader) entries(t *transaction) ([]*qif.Entry, error) {
+	var entries []*qif.Entry
+	for _, p := range t.postings {
+		if p.account != r.account {
+			continue
+		}
+		r.matched = true
+		if !p.hasAmount {
+			return nil, r.errorf(t.line, "%s: can't infer amount", p.account)
+		}
+		e := &qif.Entry{
+			Number:   t.code,
+			Date:     t.date,
+			Amount:   p.amount,
+			Currency: p.commodity,
+			Payee:    t.payee,
+			Memo:     t.memo,
+			Cleared:  t.cleared,
+		}
+		var others []*posting
+		for _, o := range t.postings {
+			if o != p {
+				others = append(others, o)
+			}
+		}
+		if len(others) == 1 {
+			e.Category = Category(others[0].account)
+		} else {
+			for _, o := range others {
+				// A split's amount is in the entry's currency, so
+				// it's the other posting's weight.
+				if o.weight == nil || !o.weight.IsInt() || o.weightCommodity != e.Currency {
+					return nil, r.errorf(t.line, "%s: can't split into %s: no amount in %s", p.account, o.account, e.Currency)
+				}
+				e.Splits = append(e.Splits, qif.Split{
+					Category: Category(o.account),
+					Amount:   -int(o.weight.Num().Int64()),
+				})
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// ReadEntry reads the next posting to the reader's account, and can be
+// called repeatedly.  Returns (nil, io.EOF) at the end of the input.
+func (r *Reader) ReadEntry() (*qif.Entry, error) {
+	for len(r.queued) == 0 {
+		t, err := r.read()
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			if !r.matched {
+				return nil, r.noMatch()
+			}
+			return nil, io.EOF
+		}
+		r.queued, err = r.entries(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	e := r.queued[0]
+	r.queued = r.queued[1:]
+	return e, nil
+}
+
+func (r *Reader) noMatch() error {
+	var accounts []string
+	for a := range r.accounts {
+		if root, _, _ := strings.Cut(a, ":"); root != "Expenses" && root != "Income" {
+			accounts = append(accounts, strconv.Quote(a))
+		}
+	}
+	sort.Strings(accounts)
+	return fmt.Errorf("journal: no postings to account %q; saw accounts %s", r.account, strings.Join(accounts, ", "))
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+func date(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+func readAll(t *testing.T, input, account string) []qif.Entry {
+	t.Helper()
+	r := NewReader(strings.NewReader(input), account)
+	var entries []qif.Entry
+	for {
+		e, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+func check(t *testing.T, entries, expects []qif.Entry) {
+	t.Helper()
+	if len(entries) != len(expects) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(expects))
+	}
+	for i, expect := range expects {
+		if !reflect.DeepEqual(entries[i], expect) {
+			t.Errorf("%d: got\n%#v\nwant\n%#v", i, entries[i], expect)
+		}
+	}
+}
+
+func TestBeancount(t *testing.T) {
+	const input = `option "title" "Books"
+2024-01-01 open Assets:Checking USD
+2024-01-01 open Expenses:Food:Restaurants
+
+; Lunch out.
+2024-01-05 * "Cafe" "Lunch with Sam" #work
+  id: "abc"
+  Expenses:Food:Restaurants   12.50 USD
+  Assets:Checking
+
+2024-01-06 ! "Paycheck"
+  Assets:Checking  2,000.00 USD
+  Income:Salary
+
+2024-01-07 txn "Groceries and cash"
+  Expenses:Food:Groceries 40.00 USD
+  Expenses:Cash 20.00 USD
+  Assets:Checking -60 USD
+
+2024-01-08 * "Move to savings"
+  Assets:Savings     100.00 USD
+  Assets:Checking
+
+2024-01-09 * "Not this account"
+  Expenses:Food:Restaurants   5.00 USD
+  Liabilities:CreditCard
+
+2024-01-31 balance Assets:Checking  1807.50 USD
+`
+	entries := readAll(t, input, "Assets:Checking")
+	check(t, entries, []qif.Entry{
+		{Date: date(2024, 1, 5), Amount: -1250, Currency: "USD", Payee: "Cafe", Memo: "Lunch with Sam",
+			Category: "Food:Restaurants", Cleared: qif.Cleared},
+		{Date: date(2024, 1, 6), Amount: 200000, Currency: "USD", Payee: "Paycheck", Category: "Salary"},
+		{Date: date(2024, 1, 7), Amount: -6000, Currency: "USD", Payee: "Groceries and cash",
+			Splits: []qif.Split{{Category: "Food:Groceries", Amount: -4000}, {Category: "Cash", Amount: -2000}}},
+		{Date: date(2024, 1, 8), Amount: -10000, Currency: "USD", Payee: "Move to savings",
+			Category: "[Assets:Savings]", Cleared: qif.Cleared},
+	})
+}
+
+func TestLedger(t *testing.T) {
+	const input = `; Ledger journal
+account Assets:My Bank
+P 2024/01/01 EUR $1.10
+
+2024/01/05 * (1001) Cafe  ; Lunch
+    Expenses:Food:Restaurants    $12.50
+    Assets:My Bank
+
+2024/01/06=2024/01/08 Refund
+    ; returned shoes
+    Assets:My Bank    -$1,234.50  ; odd sign
+    [Expenses:Clothes]
+
+2024-1-7 ! Travel
+    Expenses:Travel    €30.00 @ $1.10
+    Assets:My Bank     $-33.00
+`
+	entries := readAll(t, input, "Assets:My Bank")
+	check(t, entries, []qif.Entry{
+		{Number: "1001", Date: date(2024, 1, 5), Amount: -1250, Currency: "USD", Payee: "Cafe", Memo: "Lunch",
+			Category: "Food:Restaurants", Cleared: qif.Cleared},
+		{Date: date(2024, 1, 6), Amount: -123450, Currency: "USD", Payee: "Refund", Memo: "returned shoes",
+			Category: "Clothes"},
+		{Date: date(2024, 1, 7), Amount: -3300, Currency: "USD", Payee: "Travel", Category: "Travel"},
+	})
+}
+
+func TestPrices(t *testing.T) {
+	const input = `2024-01-05 * "Hotel"
+  Expenses:Travel  100.00 EUR @ 1.10 USD
+  Assets:Checking
+
+2024-01-06 * "Taxi"
+  Expenses:Travel  20.00 EUR @@ 22.50 USD
+  Assets:Checking
+
+2024-01-07 * "Buy shares"
+  Assets:Broker  2.5 AAPL {150.00 USD, 2024-01-07}
+  Assets:Checking
+
+2024-01-08 * "Sell shares"
+  Assets:Broker  -10 AAPL {{1,600.00 USD}}
+  Assets:Checking
+
+2024-01-09 * "Dinner and fee"
+  Expenses:Food  40.00 EUR @ 1.10 USD
+  Expenses:Fees  1.00 USD
+  Assets:Checking
+`
+	entries := readAll(t, input, "Assets:Checking")
+	check(t, entries, []qif.Entry{
+		{Date: date(2024, 1, 5), Amount: -11000, Currency: "USD", Payee: "Hotel", Category: "Travel", Cleared: qif.Cleared},
+		{Date: date(2024, 1, 6), Amount: -2250, Currency: "USD", Payee: "Taxi", Category: "Travel", Cleared: qif.Cleared},
+		{Date: date(2024, 1, 7), Amount: -37500, Currency: "USD", Payee: "Buy shares", Category: "[Assets:Broker]", Cleared: qif.Cleared},
+		{Date: date(2024, 1, 8), Amount: 160000, Currency: "USD", Payee: "Sell shares", Category: "[Assets:Broker]", Cleared: qif.Cleared},
+		{Date: date(2024, 1, 9), Amount: -4500, Currency: "USD", Payee: "Dinner and fee", Cleared: qif.Cleared,
+			Splits: []qif.Split{{Category: "Food", Amount: -4400}, {Category: "Fees", Amount: -100}}},
+	})
+}
+
+func TestCategory(t *testing.T) {
+	tests := map[string]string{
+		"Expenses:Food:Restaurants": "Food:Restaurants",
+		"Income:Salary":             "Salary",
+		"Expenses":                  "Expenses",
+		"Assets:Savings":            "[Assets:Savings]",
+		"Equity:Opening-Balances":   "[Equity:Opening-Balances]",
+		"Food":                      "Food",
+	}
+	for account, want := range tests {
+		if got := Category(account); got != want {
+			t.Errorf("%q: got %q, want %q", account, got, want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input, err string
+	}{
+		{"2024-01-05 * \"x\"\n  Expenses:A 1 USD\n  Expenses:B 2 USD\n",
+			`no postings to account "Assets:Checking"`},
+		{"2024-01-05 * \"x\"\n  Expenses:A\n  Assets:Checking\n",
+			"line 1: more than one posting without an amount"},
+		{"2024-01-05 * \"x\"\n  Expenses:A 1 USD\n  Assets:Checking 0.001 USD\n",
+			"line 1: Assets:Checking: bad amount \"0.001\": fraction of a cent"},
+		// The elided amount can't be inferred from a leg fin can't
+		// value.
+		{"2024-01-05 * \"x\"\n  Assets:Broker 10 AAPL {}\n  Assets:Checking\n",
+			"line 1: Assets:Checking: can't infer amount"},
+		{"2024-01-05 * \"x\"\n  Expenses:A 1 USD\n  Expenses:B 1 EUR\n  Assets:Checking\n",
+			"line 1: Assets:Checking: can't infer amount"},
+		{"2024-01-05 * \"x\"\n  Expenses:A 1 EUR\n  Expenses:B 1 USD\n  Assets:Checking -2 EUR\n",
+			"line 1: Assets:Checking: can't split into Expenses:B: no amount in EUR"},
+		{"2024-13-05 * \"x\"\n",
+			"line 1:"},
+	}
+	for _, test := range tests {
+		_, err := NewReader(strings.NewReader(test.input), "Assets:Checking").ReadEntry()
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: got %v, want %q", test.input, err, test.err)
+		}
+	}
+}
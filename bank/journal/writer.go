@@ -0,0 +1,155 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/evmar/fin/bank/money"
+)
+
+// Format is a journal file format.
+type Format int
+
+const (
+	Beancount Format = iota
+	Ledger
+)
+
+// ParseFormat parses a format name, "beancount" or "ledger".
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "beancount":
+		return Beancount, nil
+	case "ledger":
+		return Ledger, nil
+	}
+	return 0, fmt.Errorf("unknown journal format %q", s)
+}
+
+// Transaction is a transaction to write to a journal.
+type Transaction struct {
+	Date time.Time
+	// Cleared marks the transaction cleared in Ledger.  Beancount
+	// transactions are always written as complete ("*"), since they
+	// are already in a bank's records.
+	Cleared bool
+	// Number is a check number or other identifier, if any.
+	Number string
+	Payee  string
+	Memo   string
+
+	// Postings must sum to zero in each currency.
+	Postings []Posting
+}
+
+// Posting is one leg of a Transaction.
+type Posting struct {
+	// Account is a colon-separated account name.
+	// Sample value: "Expenses:Food:Restaurants".
+	Account string
+	// Amount is the amount in cents, in Currency.
+	Amount   int
+	Currency string
+}
+
+// Writer writes transactions in Beancount or Ledger format.
+type Writer struct {
+	w      io.Writer
+	format Format
+}
+
+func NewWriter(w io.Writer, format Format) *Writer {
+	return &Writer{w: w, format: format}
+}
+
+// oneLine collapses a string onto one line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// quote quotes a Beancount string.
+func quote(s string) string {
+	s = strings.ReplaceAll(oneLine(s), `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// Open declares an account as of date.  Beancount requires every
+// account to be opened before it is used; Ledger only checks declared
+// accounts when asked to.
+func (w *Writer) Open(date time.Time, account string) error {
+	var err error
+	switch w.format {
+	case Beancount:
+		_, err = fmt.Fprintf(w.w, "%s open %s\n", date.Format("2006-01-02"), account)
+	case Ledger:
+		_, err = fmt.Fprintf(w.w, "account %s\n", account)
+	}
+	return err
+}
+
+// WriteTransaction writes a transaction, preceded by a blank line.
+func (w *Writer) WriteTransaction(t *Transaction) error {
+	sums := map[string]int{}
+	for _, p := range t.Postings {
+		sums[p.Currency] += p.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%s %q: postings in %s sum to %s", t.Date.Format("2006-01-02"), t.Payee, currency, money.Format(sum))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\n")
+	switch w.format {
+	case Beancount:
+		fmt.Fprintf(&b, "%s * %s", t.Date.Format("2006-01-02"), quote(t.Payee))
+		if t.Memo != "" {
+			fmt.Fprintf(&b, " %s", quote(t.Memo))
+		}
+		b.WriteString("\n")
+		if t.Number != "" {
+			fmt.Fprintf(&b, "  number: %s\n", quote(t.Number))
+		}
+	case Ledger:
+		b.WriteString(t.Date.Format("2006/01/02"))
+		if t.Cleared {
+			b.WriteString(" *")
+		}
+		if t.Number != "" {
+			fmt.Fprintf(&b, " (%s)", oneLine(t.Number))
+		}
+		// A payee can't hold a comment, and a leading "(" would be
+		// read as a code.
+		payee := strings.TrimLeft(strings.ReplaceAll(oneLine(t.Payee), ";", ","), "(")
+		fmt.Fprintf(&b, " %s\n", payee)
+		if t.Memo != "" {
+			fmt.Fprintf(&b, "    ; %s\n", oneLine(t.Memo))
+		}
+	}
+	for _, p := range t.Postings {
+		indent := "  "
+		if w.format == Ledger {
+			indent = "    "
+		}
+		fmt.Fprintf(&b, "%s%-40s  %10s %s\n", indent, p.Account, money.Format(p.Amount), p.Currency)
+	}
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+var lunch = &Transaction{
+	Date:    date(2024, 1, 5),
+	Cleared: true,
+	Number:  "1001",
+	Payee:   `Cafe "Chez Nous"`,
+	Memo:    "Lunch",
+	Postings: []Posting{
+		{Account: "Assets:Checking", Amount: -1250, Currency: "USD"},
+		{Account: "Expenses:Food:Restaurants", Amount: 1250, Currency: "USD"},
+	},
+}
+
+func TestWriteBeancount(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b, Beancount)
+	if err := w.Open(date(2024, 1, 1), "Assets:Checking"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteTransaction(lunch); err != nil {
+		t.Fatal(err)
+	}
+	const expect = `2024-01-01 open Assets:Checking
+
+2024-01-05 * "Cafe \"Chez Nous\"" "Lunch"
+  number: "1001"
+  Assets:Checking                               -12.50 USD
+  Expenses:Food:Restaurants                      12.50 USD
+`
+	if b.String() != expect {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), expect)
+	}
+
+	// What's written reads back.
+	entries := readAll(t, b.String(), "Assets:Checking")
+	check(t, entries, []qif.Entry{
+		{Date: date(2024, 1, 5), Amount: -1250, Currency: "USD", Payee: `Cafe "Chez Nous"`, Memo: "Lunch",
+			Category: "Food:Restaurants", Cleared: qif.Cleared},
+	})
+}
+
+func TestWriteLedger(t *testing.T) {
+	var b strings.Builder
+	if err := NewWriter(&b, Ledger).WriteTransaction(lunch); err != nil {
+		t.Fatal(err)
+	}
+	const expect = `
+2024/01/05 * (1001) Cafe "Chez Nous"
+    ; Lunch
+    Assets:Checking                               -12.50 USD
+    Expenses:Food:Restaurants                      12.50 USD
+`
+	if b.String() != expect {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), expect)
+	}
+
+	entries := readAll(t, b.String(), "Assets:Checking")
+	check(t, entries, []qif.Entry{
+		{Number: "1001", Date: date(2024, 1, 5), Amount: -1250, Currency: "USD", Payee: `Cafe "Chez Nous"`, Memo: "Lunch",
+			Category: "Food:Restaurants", Cleared: qif.Cleared},
+	})
+}
+
+func TestWriteUnbalanced(t *testing.T) {
+	err := NewWriter(&strings.Builder{}, Ledger).WriteTransaction(&Transaction{
+		Date:     date(2024, 1, 5),
+		Payee:    "x",
+		Postings: []Posting{{Account: "Assets:A", Amount: 1, Currency: "USD"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "sum to 0.01") {
+		t.Errorf("expected unbalanced error, got %v", err)
+	}
+}
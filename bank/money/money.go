@@ -0,0 +1,272 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package money parses amounts of money as written in bank statements
+// into exact integer cents, without a detour through floating point.
+package money
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parse parses an amount of money into cents.  It accepts the forms
+// found in bank exports:
+//
+//   - currency symbols and codes: "$1.50", "1.50 EUR", "US$1.50"
+//   - thousands separators: "1,234.50", "1.234,50", "1'234.50", "1 234,50"
+//   - decimal commas: "12,50"
+//   - negatives as "-1.50", "- $1.50", "1.50-", "(1.50)", or "1.50 DR"
+//
+// Signs, symbols, and letters may only come before or after the
+// number, and the only words allowed are currency codes like "EUR" and
+// a trailing "DR" or "CR".  Anything else, like "1e5", is an error
+// rather than being dropped.
+//
+// A lone separator followed by exactly three digits, as in "1,234", is
+// taken as a thousands separator, unless only zeros precede it as in
+// "0.125".  Machine formats that always use a decimal point should use
+// ParseDecimal instead.
+func Parse(s string) (int, error) {
+	bad := func(why string) (int, error) {
+		return 0, fmt.Errorf("bad amount %q: %s", s, why)
+	}
+
+	str := strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(str, "(") && strings.HasSuffix(str, ")") {
+		neg = true
+		str = str[1 : len(str)-1]
+	}
+
+	start, end := numberSpan(str)
+	if start == -1 {
+		return bad("no digits")
+	}
+
+	var num strings.Builder
+	core := []rune(str[start:end])
+	for i, r := range core {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',':
+			num.WriteRune(r)
+		case r == '\'', r == '’', unicode.IsSpace(r):
+			// Swiss or spaced thousands separator.
+			if !groupFollows(core[i+1:]) {
+				return bad(fmt.Sprintf("misplaced %q", r))
+			}
+		default:
+			return bad(fmt.Sprintf("unexpected %q", r))
+		}
+	}
+
+	signs := 0
+	for _, affix := range []struct {
+		text   string
+		suffix bool
+	}{{str[:start], false}, {str[end:], true}} {
+		var word []rune
+		flush := func(next rune) error {
+			w := string(word)
+			word = nil
+			switch {
+			case w == "":
+			case affix.suffix && strings.EqualFold(w, "DR"):
+				neg = !neg
+			case affix.suffix && strings.EqualFold(w, "CR"):
+			case currencyWord(w, next):
+			default:
+				return fmt.Errorf("unexpected %q", w)
+			}
+			return nil
+		}
+		for _, r := range affix.text {
+			if unicode.IsLetter(r) {
+				word = append(word, r)
+				continue
+			}
+			if err := flush(r); err != nil {
+				return bad(err.Error())
+			}
+			switch {
+			case r == '-', r == '−':
+				neg = !neg
+				signs++
+			case r == '+':
+				signs++
+			case unicode.IsSpace(r), unicode.Is(unicode.Sc, r):
+			default:
+				return bad(fmt.Sprintf("unexpected %q", r))
+			}
+		}
+		if err := flush(0); err != nil {
+			return bad(err.Error())
+		}
+	}
+	if signs > 1 {
+		return bad("multiple signs")
+	}
+
+	whole, frac, err := splitDecimal(num.String())
+	if err != nil {
+		return bad(err.Error())
+	}
+	return cents(whole, frac, neg, bad)
+}
+
+// numberSpan returns the start and end of the number in s: from its
+// first digit, or a separator just before one, to its last digit and
+// any separator just after it.  start is -1 if there are no digits.
+func numberSpan(s string) (start, end int) {
+	isDigit := func(i int) bool { return i < len(s) && s[i] >= '0' && s[i] <= '9' }
+	isSep := func(i int) bool { return i < len(s) && (s[i] == '.' || s[i] == ',') }
+	start = -1
+	for i := 0; i < len(s); i++ {
+		if isDigit(i) || (isSep(i) && isDigit(i+1)) {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return -1, -1
+	}
+	end = strings.LastIndexAny(s, "0123456789") + 1
+	if isSep(end) {
+		end++
+	}
+	return start, end
+}
+
+// groupFollows reports whether rs starts with a group of exactly three
+// digits, as follows a thousands separator.
+func groupFollows(rs []rune) bool {
+	for i := 0; i < 3; i++ {
+		if i >= len(rs) || rs[i] < '0' || rs[i] > '9' {
+			return false
+		}
+	}
+	return len(rs) == 3 || rs[3] < '0' || rs[3] > '9'
+}
+
+// currencyWord reports whether w, followed by the rune next, names a
+// currency: a code like "EUR", a prefix of a symbol like the "US" of
+// "US$", or the "kr" of the Nordic crowns.
+func currencyWord(w string, next rune) bool {
+	if strings.EqualFold(w, "kr") {
+		return true
+	}
+	if strings.Trim(w, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return false
+	}
+	return len(w) == 3 || (len(w) <= 2 && unicode.Is(unicode.Sc, next))
+}
+
+// Format formats cents as a plain decimal, e.g. "-1234.50", which both
+// Parse and ParseDecimal accept.
+func Format(cents int) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// ParseDecimal parses an amount as written by machine formats such as
+// OFX and ISO 20022: an optional sign, digits, and optionally a decimal
+// separator and more digits, e.g. "-12.500".  The separator is a point
+// or, as OFX allows, a comma.  There are no thousands separators, so
+// unlike Parse it never mistakes a decimal separator for one.
+func ParseDecimal(s string) (int, error) {
+	bad := func(why string) (int, error) {
+		return 0, fmt.Errorf("bad amount %q: %s", s, why)
+	}
+
+	str := strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(str, "-") {
+		neg = true
+		str = str[1:]
+	} else if strings.HasPrefix(str, "+") {
+		str = str[1:]
+	}
+	whole, frac, ok := strings.Cut(str, ".")
+	if !ok {
+		whole, frac, _ = strings.Cut(str, ",")
+	}
+	if whole == "" && frac == "" {
+		return bad("no digits")
+	}
+	if strings.Trim(whole+frac, "0123456789") != "" {
+		return bad("not a decimal number")
+	}
+	return cents(whole, frac, neg, bad)
+}
+
+// cents combines the whole and fractional digits of an amount into
+// cents, failing with bad if they don't make a whole number of cents.
+func cents(whole, frac string, neg bool, bad func(why string) (int, error)) (int, error) {
+	if len(frac) > 2 {
+		if strings.Trim(frac[2:], "0") != "" {
+			return bad("fraction of a cent")
+		}
+		frac = frac[:2]
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	n, err := strconv.Atoi(whole + frac)
+	if err != nil {
+		return bad("out of range")
+	}
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
+
+// splitDecimal splits a number containing digits and separators into
+// its whole and fractional digits.
+func splitDecimal(num string) (whole, frac string, err error) {
+	lastDot := strings.LastIndexByte(num, '.')
+	lastComma := strings.LastIndexByte(num, ',')
+
+	var dec int
+	switch {
+	case lastDot == -1 && lastComma == -1:
+		return num, "", nil
+	case lastDot != -1 && lastComma != -1:
+		// Whichever comes last is the decimal separator.
+		dec = max(lastDot, lastComma)
+	default:
+		sep := num[max(lastDot, lastComma)]
+		dec = max(lastDot, lastComma)
+		thousands := len(num)-dec-1 == 3 && strings.TrimLeft(num[:dec], "0") != ""
+		if strings.Count(num, string(sep)) > 1 || thousands {
+			// Only thousands separators.
+			dec = -1
+		}
+	}
+
+	if dec == -1 {
+		whole = num
+	} else {
+		whole, frac = num[:dec], num[dec+1:]
+		if strings.IndexByte(whole, num[dec]) != -1 {
+			return "", "", fmt.Errorf("misplaced separator")
+		}
+	}
+	whole = strings.NewReplacer(",", "", ".", "").Replace(whole)
+	return whole, frac, nil
+}
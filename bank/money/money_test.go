@@ -0,0 +1,135 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package money
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"0", 0},
+		{"19.99", 1999},
+		{"-19.99", -1999},
+		{"1,592.65", 159265},
+		{"-1,592.65", -159265},
+		{"12345678.91", 1234567891},
+		{"3000.00", 300000},
+		{".5", 50},
+		{"12.", 1200},
+		{"$1,234.56", 123456},
+		{"- $175.00", -17500},
+		{"+ $5.00", 500},
+		{"(12.34)", -1234},
+		{"12.34-", -1234},
+		{"1.234,56", 123456},
+		{"1.234,56 €", 123456},
+		{"-12,5", -1250},
+		{"1,234", 123400},
+		{"1.234.567", 123456700},
+		{"1'234.50", 123450},
+		{"EUR 10.00", 1000},
+		{"10.00 DR", -1000},
+		{"10.00 CR", 1000},
+		{"-42.0000", -4200},
+		{"0.120", 12},
+		{"-0,500", -50},
+		{"12.500", 1250000},
+		{"US$1.50", 150},
+		{"1 234,50", 123450},
+		{"1 234,50 €", 123450},
+		{"12,50 kr", 1250},
+		{"10.00 dr", -1000},
+		{"$ -1.50", -150},
+		{"−3.00", -300},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.in, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Parse(%q) = %d, want %d", test.in, got, test.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, in := range []string{"", "$", "--1", "1.0051", "1,2.3,4", "12#", "0.125",
+		"1e5", "12O.50", "abc1", "1-2", "1 2", "1'23", "12 EURO", "DR 10", "- 1.50-"} {
+		if got, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %d, expected error", in, got)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"0", 0},
+		{"-12.500", -1250},
+		{"12.500", 1250},
+		{"+5", 500},
+		{"1234.5", 123450},
+		{"-.25", -25},
+		{"100.00000", 10000},
+		{" 3.10 ", 310},
+		{"-12,50", -1250},
+		{"0,120000", 12},
+	}
+	for _, test := range tests {
+		got, err := ParseDecimal(test.in)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", test.in, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseDecimal(%q) = %d, want %d", test.in, got, test.want)
+		}
+	}
+	for _, in := range []string{"", "-", "0.125", "1,234.50", "1.2.3", "$5", "--1", "1,234,50", "1,2.5"} {
+		if got, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) = %d, expected error", in, got)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		in   int
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-1250, "-12.50"},
+		{123456, "1234.56"},
+		{-12500, "-125.00"},
+	}
+	for _, test := range tests {
+		got := Format(test.in)
+		if got != test.want {
+			t.Errorf("Format(%d) = %q, want %q", test.in, got, test.want)
+		}
+		for _, parse := range []func(string) (int, error){Parse, ParseDecimal} {
+			if n, err := parse(got); err != nil || n != test.in {
+				t.Errorf("Format(%d) = %q, which parses as %d, %v", test.in, got, n, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mt940
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/evmar/fin/bank"
+	"github.com/evmar/fin/bank/money"
+)
+
+func init() {
+	bank.Register(&bank.Format{
+		Name:        "mt940",
+		Description: "SWIFT MT940 statements",
+		Extensions:  []string{".sta", ".mt940", ".940"},
+		Sniff:       sniff,
+		Open:        open,
+	})
+}
+
+var (
+	envelopeRE  = regexp.MustCompile(`^\s*\{1:`)
+	referenceRE = regexp.MustCompile(`(?m)^:20:`)
+	accountRE   = regexp.MustCompile(`(?m)^:25:`)
+)
+
+// sniff recognizes MT940 by its SWIFT envelope, or else by the
+// statement reference and account fields that start each statement.
+func sniff(prefix []byte) bool {
+	return envelopeRE.Match(prefix) || (referenceRE.Match(prefix) && accountRE.Match(prefix))
+}
+
+func open(r io.Reader, opts *bank.Options) (bank.Reader, error) {
+	return NewReader(r), nil
+}
+
+// Summary returns the balances of each statement read.
+func (r *Reader) Summary() []string {
+	var lines []string
+	for _, stmt := range r.Statements() {
+		lines = append(lines, fmt.Sprintf("%s: balance %s %s, %s %s", stmt.Account,
+			stmt.OpeningDate.Format("2006/01/02"), money.Format(stmt.Opening),
+			stmt.ClosingDate.Format("2006/01/02"), money.Format(stmt.Closing)))
+	}
+	return lines
+}
@@ -0,0 +1,329 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mt940 parses SWIFT MT940 customer statements, which many
+// banks offer for business accounts.
+package mt940
+
+// An MT940 file is a series of statements, each a series of fields
+// like ":61:" that may continue over several lines, ending with a line
+// holding "-".  The fields read are:
+//
+//	:20:  statement reference
+//	:25:  account
+//	:28C: statement number
+//	:60F: opening balance (:60M: for a continued statement)
+//	:61:  a transaction
+//	:86:  the narrative of the preceding transaction
+//	:62F: closing balance (:62M: for a continued statement)
+//
+// Other fields, such as the available balance in :64:, are skipped.
+// Amounts have a decimal comma and no sign or thousands separators,
+// e.g. "1234,5"; the sign comes from a separate debit/credit mark.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/money"
+	"github.com/evmar/fin/bank/qif"
+)
+
+// Statement describes the statement that the most recently read
+// entries came from.
+type Statement struct {
+	// Reference is the sender's reference for the statement, from :20:.
+	Reference string
+
+	// Account identifies the account, from :25:.  It is often a bank
+	// code and account number.  Sample value: "10020030/1234567".
+	Account string
+
+	// Number is the statement number and page, from :28C:.
+	// Sample value: "00001/001".
+	Number string
+
+	// Currency is the currency of the account, from the opening
+	// balance.  Sample value: "EUR".
+	Currency string
+
+	// Opening and Closing are the booked balances, in cents, at the
+	// start and end of the statement, as of OpeningDate and
+	// ClosingDate.  The closing balance is only filled in once all of
+	// a statement's entries are read.
+	Opening     int
+	OpeningDate time.Time
+	Closing     int
+	ClosingDate time.Time
+}
+
+type Reader struct {
+	s       *bufio.Scanner
+	lineNum int
+
+	// pending is a line that was read ahead while looking for the end
+	// of a field.
+	pending *string
+	// unreadTag and unreadLines are a field that was read ahead while
+	// looking for a transaction's narrative.
+	unreadTag   string
+	unreadLines []string
+
+	stmt  *Statement
+	stmts []*Statement
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{s: bufio.NewScanner(charset.NewReader(r, ""))}
+}
+
+// Statement returns the statement containing the most recently read
+// entry, or nil if no statement has been seen yet.
+func (r *Reader) Statement() *Statement {
+	return r.stmt
+}
+
+// Statements returns all the statements read so far, e.g. to check
+// their balances once the whole input is read.
+func (r *Reader) Statements() []*Statement {
+	return r.stmts
+}
+
+func (r *Reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", r.lineNum, fmt.Sprintf(format, args...))
+}
+
+func (r *Reader) line() (string, bool) {
+	if l := r.pending; l != nil {
+		r.pending = nil
+		return *l, true
+	}
+	if !r.s.Scan() {
+		return "", false
+	}
+	r.lineNum++
+	return strings.TrimRight(r.s.Text(), " \r"), true
+}
+
+var tagRE = regexp.MustCompile(`^:(\d\d[A-Z]?):`)
+
+// field reads the next field, returning its tag and its lines.  The
+// end of a statement is returned as the tag "-".  Returns an empty tag
+// at the end of the input.
+func (r *Reader) field() (string, []string, error) {
+	if r.unreadTag != "" {
+		tag, lines := r.unreadTag, r.unreadLines
+		r.unreadTag, r.unreadLines = "", nil
+		return tag, lines, nil
+	}
+	var tag string
+	var lines []string
+	for {
+		l, ok := r.line()
+		if !ok {
+			if err := r.s.Err(); err != nil {
+				return "", nil, err
+			}
+			return tag, lines, nil
+		}
+		if tag == "" {
+			// Skip the SWIFT envelope, e.g. "{1:F01...}{2:...}{4:",
+			// and blank lines between statements.
+			if i := strings.Index(l, "{4:"); i >= 0 {
+				l = l[i+3:]
+			}
+			switch {
+			case l == "" || strings.HasPrefix(l, "{"):
+				continue
+			case l == "-" || strings.HasPrefix(l, "-}"):
+				return "-", nil, nil
+			}
+			m := tagRE.FindStringSubmatch(l)
+			if m == nil {
+				return "", nil, r.errorf("expected field, got %q", l)
+			}
+			tag = m[1]
+			lines = append(lines, l[len(m[0]):])
+			continue
+		}
+		if tagRE.MatchString(l) || l == "-" || strings.HasPrefix(l, "-}") {
+			r.pending = &l
+			return tag, lines, nil
+		}
+		lines = append(lines, l)
+	}
+}
+
+func parseDate(s string) (time.Time, error) {
+	t, err := time.Parse("060102", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad date %q", s)
+	}
+	return t, nil
+}
+
+// balanceRE matches a balance, e.g. "C240101EUR1000,00".
+var balanceRE = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})([\d,]+)$`)
+
+func (r *Reader) balance(tag, s string) error {
+	m := balanceRE.FindStringSubmatch(s)
+	if m == nil {
+		return r.errorf(":%s: bad balance %q", tag, s)
+	}
+	date, err := parseDate(m[2])
+	if err != nil {
+		return r.errorf(":%s: %v", tag, err)
+	}
+	n, err := money.ParseDecimal(m[4])
+	if err != nil {
+		return r.errorf(":%s: %v", tag, err)
+	}
+	if m[1] == "D" {
+		n = -n
+	}
+	if tag[:2] == "60" {
+		r.stmt.Currency = m[3]
+		r.stmt.Opening, r.stmt.OpeningDate = n, date
+	} else {
+		r.stmt.Closing, r.stmt.ClosingDate = n, date
+	}
+	return nil
+}
+
+// transactionRE matches the first line of a :61: field: the value
+// date, optional booking date (MMDD), debit/credit mark, optional funds
+// code, amount, transaction type, and references.
+var transactionRE = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?([\d,]+)([NSF][A-Z0-9]{3})(.*?)(?://(.*))?$`)
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// transaction converts a :61: field into an Entry.
+func (r *Reader) transaction(lines []string) (*qif.Entry, error) {
+	m := transactionRE.FindStringSubmatch(lines[0])
+	if m == nil {
+		return nil, r.errorf(":61: bad transaction %q", lines[0])
+	}
+	e := &qif.Entry{
+		Cleared:  qif.Cleared,
+		Currency: r.stmt.Currency,
+		Account:  r.stmt.Account,
+		Type:     m[6],
+	}
+	var err error
+	e.Date, err = parseDate(m[1])
+	if err != nil {
+		return nil, r.errorf(":61: %v", err)
+	}
+	if m[2] != "" {
+		// The booking date has no year; take the one that puts it
+		// nearest the value date.
+		var booked time.Time
+		for _, year := range []int{e.Date.Year() - 1, e.Date.Year(), e.Date.Year() + 1} {
+			t, err := time.Parse("20060102", fmt.Sprintf("%04d%s", year, m[2]))
+			if err == nil && (booked.IsZero() || abs(t.Sub(e.Date)) < abs(booked.Sub(e.Date))) {
+				booked = t
+			}
+		}
+		if booked.IsZero() {
+			return nil, r.errorf(":61: bad booking date %q", m[2])
+		}
+		if !booked.Equal(e.Date) {
+			e.ValueDate, e.Date = e.Date, booked
+		}
+	}
+	e.Amount, err = money.ParseDecimal(m[5])
+	if err != nil {
+		return nil, r.errorf(":61: %v", err)
+	}
+	// A reversal of a credit (RC) takes money out, and a reversal of a
+	// debit (RD) puts it back.
+	if m[3] == "D" || m[3] == "RC" {
+		e.Amount = -e.Amount
+	}
+	// The bank's reference is unique within the account, so it is
+	// preferred; the customer's is often the placeholder "NONREF".
+	// Without either, the narrative's end-to-end ID is used.
+	if ref := strings.TrimSpace(m[8]); ref != "" {
+		e.Number = ref
+	} else if ref := strings.TrimSpace(m[7]); ref != "NONREF" && ref != "NOTPROVIDED" {
+		e.Number = ref
+	}
+	if len(lines) > 1 {
+		e.Memo = strings.TrimSpace(strings.Join(lines[1:], " "))
+	}
+	return e, nil
+}
+
+// ReadEntry reads the next transaction, and can be called repeatedly.
+// Returns (nil, io.EOF) at the end of the input.
+func (r *Reader) ReadEntry() (*qif.Entry, error) {
+	for {
+		tag, lines, err := r.field()
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case "":
+			if r.stmt == nil {
+				return nil, fmt.Errorf("no MT940 statement found")
+			}
+			return nil, io.EOF
+		case "-":
+			continue
+		case "20":
+			r.stmt = &Statement{Reference: lines[0]}
+			r.stmts = append(r.stmts, r.stmt)
+			continue
+		}
+		if r.stmt == nil {
+			return nil, r.errorf(":%s: field before :20:", tag)
+		}
+		switch tag {
+		case "25":
+			r.stmt.Account = lines[0]
+		case "28C":
+			r.stmt.Number = lines[0]
+		case "60F", "60M", "62F", "62M":
+			if err := r.balance(tag, lines[0]); err != nil {
+				return nil, err
+			}
+		case "61":
+			e, err := r.transaction(lines)
+			if err != nil {
+				return nil, err
+			}
+			tag, lines, err := r.field()
+			if err != nil {
+				return nil, err
+			}
+			if tag == "86" {
+				narrative(e, lines)
+			} else {
+				// Not a narrative; read it again on the next call.
+				r.unreadTag, r.unreadLines = tag, lines
+			}
+			return e, nil
+		}
+	}
+}
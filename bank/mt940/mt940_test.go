@@ -0,0 +1,163 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mt940
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+func date(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+func readAll(t *testing.T, r *Reader) []qif.Entry {
+	t.Helper()
+	var entries []qif.Entry
+	for {
+		e, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+const sample = `:20:STARTUMSE
+:25:10020030/1234567
+:28C:00001/001
+:60F:C231229EUR1000,00
+:61:2312291230DR49,45NMSCNONREF
+:86:177?00SEPA-UEBERWEISUNG?109310?20EREF+E2E-1?21SVWZ+Invoice 123 Jan
+?22uary?30BYLADEM1001?31DE02120300000000202051?32Stadtwerke Muster
+?33stadt
+:61:2401020102CR1000,NTRFNONREF//BANKREF2
+:86:/TRTP/SEPA OVERBOEKING/IBAN/NL12ABNA0123456789/BIC/ABNANL2A/NAME/A
+CME BV/REMI/USTD//Payment 42/EREF/NOTPROVIDED
+:61:240103RD5,NCHGNONREF
+CHARGE REFUND
+:86:Bank charges refund
+:62F:C240103EUR1955,55
+-
+:20:STMT2
+:25:NL12ABNA0123456789
+:60F:C240101EUR0,
+:61:240105D12,5NTRFNONREF
+:62F:D240105EUR12,5
+-
+`
+
+func TestStatement(t *testing.T) {
+	r := NewReader(strings.NewReader(sample))
+	expects := []qif.Entry{
+		{Number: "E2E-1", Date: date(2023, 12, 30), ValueDate: date(2023, 12, 29), Amount: -4945, Currency: "EUR",
+			Payee: "Stadtwerke Musterstadt", Memo: "Invoice 123 January", Type: "NMSC", Cleared: qif.Cleared, Account: "10020030/1234567"},
+		{Number: "BANKREF2", Date: date(2024, 1, 2), Amount: 100000, Currency: "EUR",
+			Payee: "ACME BV", Memo: "Payment 42", Type: "NTRF", Cleared: qif.Cleared, Account: "10020030/1234567"},
+		{Date: date(2024, 1, 3), Amount: 500, Currency: "EUR",
+			Payee: "Bank charges refund", Memo: "CHARGE REFUND", Type: "NCHG", Cleared: qif.Cleared, Account: "10020030/1234567"},
+	}
+	for i, expect := range expects {
+		e, err := r.ReadEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*e, expect) {
+			t.Errorf("%d: got\n%#v\nwant\n%#v", i, *e, expect)
+		}
+	}
+
+	if stmt := r.Statement(); stmt.Reference != "STARTUMSE" {
+		t.Errorf("statement: got %q", stmt.Reference)
+	}
+}
+
+func TestBalances(t *testing.T) {
+	r := NewReader(strings.NewReader(sample))
+	readAll(t, r)
+	expects := []*Statement{
+		{Reference: "STARTUMSE", Account: "10020030/1234567", Number: "00001/001", Currency: "EUR",
+			Opening: 100000, OpeningDate: date(2023, 12, 29), Closing: 195555, ClosingDate: date(2024, 1, 3)},
+		{Reference: "STMT2", Account: "NL12ABNA0123456789", Currency: "EUR",
+			OpeningDate: date(2024, 1, 1), Closing: -1250, ClosingDate: date(2024, 1, 5)},
+	}
+	if stmts := r.Statements(); !reflect.DeepEqual(stmts, expects) {
+		t.Errorf("got\n%#v\nwant\n%#v", stmts, expects)
+	}
+	summary := []string{
+		"10020030/1234567: balance 2023/12/29 1000.00, 2024/01/03 1955.55",
+		"NL12ABNA0123456789: balance 2024/01/01 0.00, 2024/01/05 -12.50",
+	}
+	if got := r.Summary(); !reflect.DeepEqual(got, summary) {
+		t.Errorf("summary: got %q, want %q", got, summary)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	const input = "{1:F01BANKDEFFAXXX0000000000}{2:O9400000000000BANKDEFFAXXX00000000000000000000N}{4:\r\n" +
+		":20:REF\r\n:25:ACCT\r\n:60F:C240101USD0,\r\n" +
+		":61:240102C3,50NTRFNONREF\r\n:86:Deposit\r\n:62F:C240102USD3,50\r\n-}\r\n"
+	entries := readAll(t, NewReader(strings.NewReader(input)))
+	expects := []qif.Entry{
+		{Date: date(2024, 1, 2), Amount: 350, Currency: "USD", Payee: "Deposit", Type: "NTRF", Cleared: qif.Cleared, Account: "ACCT"},
+	}
+	if !reflect.DeepEqual(entries, expects) {
+		t.Errorf("got\n%#v\nwant\n%#v", entries, expects)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	const input = ":20:REF\n:25:ACCT\n:60F:C240101EUR0,\n" +
+		":61:240102C1,NTRFNONREF//BANKREF\n:86:166?20EREF+E2E-1\n" +
+		":61:240102C2,NTRFCUSTREF\n:86:166?20EREF+E2E-2\n" +
+		":61:240102C3,NTRFNONREF\n:86:166?20EREF+E2E-3\n" +
+		":61:240102C4,NTRFNOTPROVIDED\n:86:166?20EREF+NOTPROVIDED\n" +
+		":62F:C240102EUR10,\n-\n"
+	var numbers []string
+	for _, e := range readAll(t, NewReader(strings.NewReader(input))) {
+		numbers = append(numbers, e.Number)
+	}
+	expect := []string{"BANKREF", "CUSTREF", "E2E-3", ""}
+	if !reflect.DeepEqual(numbers, expect) {
+		t.Errorf("got %q, want %q", numbers, expect)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"hello\n", "line 1: expected field"},
+		{":25:ACCT\n", "line 1: :25: field before :20:"},
+		{":20:X\n:61:2401xxC1,00NTRF\n", "line 2: :61: bad transaction"},
+		{":20:X\n:60F:C240101EUR1,001\n", "line 2: :60F: bad amount"},
+	}
+	for _, test := range tests {
+		_, err := NewReader(strings.NewReader(test.input)).ReadEntry()
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: got %v, want %q", test.input, err, test.err)
+		}
+	}
+}
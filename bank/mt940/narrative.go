@@ -0,0 +1,174 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mt940
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+// The :86: narrative is free text, but banks structure it in one of two
+// common ways:
+//
+// German banks start with a three-digit transaction code followed by
+// subfields introduced by a separator, usually '?':
+//
+//	166?00GUTSCHRIFT?20EREF+E2E-1?21SVWZ+Invoice 123?32ACME GmbH
+//
+// where ?20-?29 and ?60-?63 hold the purpose and ?32-?33 the name of
+// the counterparty.  The purpose in turn may hold SEPA fields such as
+// "EREF+" (end-to-end ID) and "SVWZ+" (remittance information).
+//
+// Dutch and other banks use SWIFT-style codes between slashes:
+//
+//	/TRTP/SEPA OVERBOEKING/NAME/ACME BV/REMI/Invoice 123/EREF/E2E-1
+//
+// Anything else is kept as text.
+
+// sepaRE matches the SEPA field names in a German purpose.
+var sepaRE = regexp.MustCompile(`(EREF|KREF|MREF|CRED|DEBT|COAM|OAMT|SVWZ|ABWA|ABWE|IBAN|BIC)\+`)
+
+// swiftCodes are the codes of slash-separated narratives.
+var swiftCodes = map[string]bool{
+	"TRTP": true, "NAME": true, "REMI": true, "EREF": true, "IBAN": true,
+	"BIC": true, "CNTP": true, "CSID": true, "MARF": true, "PREF": true,
+	"ORDP": true, "BENM": true, "ADDR": true, "ULTC": true, "ULTD": true,
+	"ULTB": true, "PURP": true, "RTRN": true, "ISDT": true, "SVCL": true,
+}
+
+// setNumber sets the entry's number to the end-to-end ID of the
+// payment, unless the :61: line already gave a reference or the ID is
+// the placeholder for none.
+func setNumber(e *qif.Entry, eref string) {
+	eref = strings.TrimSpace(eref)
+	if e.Number == "" && eref != "" && eref != "NOTPROVIDED" {
+		e.Number = eref
+	}
+}
+
+// narrative fills in the entry's payee and memo from a :86: field.
+func narrative(e *qif.Entry, lines []string) {
+	text := strings.Join(lines, "")
+	switch {
+	case len(text) > 4 && isDigits(text[:3]) && strings.ContainsRune("?@/|", rune(text[3])):
+		germanNarrative(e, text[4:], text[3])
+	case strings.HasPrefix(text, "/") && swiftCodes[strings.SplitN(text[1:], "/", 2)[0]]:
+		swiftNarrative(e, text)
+	default:
+		if e.Payee == "" {
+			e.Payee = clean(strings.Join(lines, " "))
+		} else {
+			e.Memo = clean(strings.Join(lines, " "))
+		}
+	}
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// clean collapses runs of spaces.
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func germanNarrative(e *qif.Entry, text string, sep byte) {
+	var purpose []string
+	var name, posting string
+	for _, sub := range strings.Split(text, string(sep)) {
+		if len(sub) < 2 {
+			continue
+		}
+		code, value := sub[:2], sub[2:]
+		switch {
+		case code == "00":
+			posting = value
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			purpose = append(purpose, value)
+		case code == "32", code == "33":
+			name += value
+		}
+	}
+
+	memo := strings.Join(purpose, "")
+	if loc := sepaRE.FindStringIndex(memo); loc != nil && loc[0] == 0 {
+		fields := map[string]string{}
+		matches := sepaRE.FindAllStringSubmatchIndex(memo, -1)
+		for i, m := range matches {
+			end := len(memo)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+			fields[memo[m[2]:m[3]]] = memo[m[1]:end]
+		}
+		setNumber(e, fields["EREF"])
+		memo = fields["SVWZ"]
+	} else {
+		memo = strings.Join(purpose, " ")
+	}
+
+	e.Payee = clean(name)
+	if e.Payee == "" {
+		e.Payee = clean(posting)
+	}
+	if memo = clean(memo); memo != "" {
+		e.Memo = memo
+	}
+}
+
+func swiftNarrative(e *qif.Entry, text string) {
+	fields := map[string]string{}
+	var code string
+	var value []string
+	flush := func() {
+		if code != "" {
+			fields[code] = strings.Trim(strings.Join(value, "/"), "/")
+		}
+	}
+	for _, part := range strings.Split(text[1:], "/") {
+		if swiftCodes[part] {
+			flush()
+			code, value = part, nil
+			continue
+		}
+		value = append(value, part)
+	}
+	flush()
+
+	name := fields["NAME"]
+	if name == "" {
+		// CNTP is account/BIC/name/city.
+		if parts := strings.Split(fields["CNTP"], "/"); len(parts) > 2 {
+			name = parts[2]
+		}
+	}
+	e.Payee = clean(name)
+	if e.Payee == "" {
+		e.Payee = clean(fields["TRTP"])
+	}
+	// Unstructured remittance information is sometimes marked "USTD//".
+	remi := strings.TrimPrefix(fields["REMI"], "USTD//")
+	if remi = clean(remi); remi != "" {
+		e.Memo = remi
+	}
+	setNumber(e, fields["EREF"])
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qfx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/evmar/fin/bank"
+	"github.com/evmar/fin/bank/money"
+)
+
+func init() {
+	bank.Register(&bank.Format{
+		Name:        "ofx",
+		Description: "Open Financial Exchange, including Quicken's QFX",
+		Extensions:  []string{".ofx", ".qfx"},
+		Sniff:       sniff,
+		Open:        open,
+	})
+}
+
+// sniff recognizes OFX 1.x by its header and OFX 2.x by its processing
+// instruction, or either by the OFX element when there's no header.
+func sniff(prefix []byte) bool {
+	text := bytes.TrimSpace(prefix)
+	return bytes.HasPrefix(text, []byte("OFXHEADER:")) ||
+		bytes.Contains(text, []byte("<?OFX ")) ||
+		bytes.HasPrefix(bytes.ToUpper(text), []byte("<OFX>"))
+}
+
+func open(r io.Reader, opts *bank.Options) (bank.Reader, error) {
+	qr := NewReader(r)
+	if _, err := qr.ReadHeader(); err != nil {
+		return nil, err
+	}
+	return qr, nil
+}
+
+// Summary returns the balance of the last statement read.
+func (r *Reader) Summary() []string {
+	if r.stmt == nil || r.stmt.BalanceDate.IsZero() {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: balance %s %s", r.stmt.AccountID,
+		r.stmt.BalanceDate.Format("2006/01/02"), money.Format(r.stmt.Balance))}
+}
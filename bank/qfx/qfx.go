@@ -12,22 +12,29 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package qfx is an incomplete parser for Quicken's OFX format.
+// Package qfx parses Quicken's OFX format, reading the transactions
+// from bank and credit card statements.
 package qfx
 
 // QFX is Quicken's variant of OFX.  OFX has a downloadable spec at
 // http://www.ofx.net; the current spec is 3.9mb when zipped.  :~(
 //
-// NOTE: this implementation is incomplete.  I discovered that my
-// exports less data via QFX than it does via the much simpler QIF
+// NOTE: only statement transactions are read.  I discovered that my
+// bank exports less data via QFX than it does via the much simpler QIF
 // format.
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"html"
 	"io"
-	"log"
 	"strings"
+	"time"
+
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/money"
+	"github.com/evmar/fin/bank/qif"
 )
 
 type Token int
@@ -38,128 +45,259 @@ const (
 	tOpenTag
 	tCloseTag
 	tText
+	tProcInst
 )
 
+// scanner splits OFX input into tokens.  It streams from its input,
+// accepts both CRLF and LF line endings, and doesn't care how the body
+// is split into lines.
 type scanner struct {
-	r      io.Reader
-	cur    []byte
-	buf    [16 << 10]byte
+	r      *bufio.Reader
 	inBody bool
+
+	// off is the byte offset of the next unread byte, and tokOff the
+	// offset of the start of the most recent token.
+	off    int64
+	tokOff int64
+
+	// buf holds the data of the most recent token.
+	buf []byte
+}
+
+// errorf returns an error annotated with the offset of the most recent
+// token.
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", s.tokOff, fmt.Sprintf(format, args...))
+}
+
+func (s *scanner) peek() (byte, error) {
+	b, err := s.r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
 }
 
-func (s *scanner) fill() error {
-	if cap(s.cur)-len(s.cur) < 1024 {
-		log.Printf("shifting %d bytes back", len(s.cur))
-		copy(s.buf[0:len(s.cur)], s.cur)
-		s.cur = s.buf[0:len(s.cur)]
+func (s *scanner) readByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err == nil {
+		s.off++
 	}
+	return b, err
+}
 
-	if s.r != nil {
-		n, err := s.r.Read(s.buf[len(s.cur):cap(s.cur)])
-		log.Printf("read %d bytes", n)
-		s.cur = s.cur[0 : len(s.cur)+n]
+// readThrough appends bytes to s.buf up to and including delim.
+func (s *scanner) readThrough(delim string, what string) error {
+	for !bytes.HasSuffix(s.buf, []byte(delim)) {
+		b, err := s.readByte()
 		if err != nil {
 			if err == io.EOF {
-				log.Printf("EOF")
-				s.r = nil
-			} else {
-				return err
+				return s.errorf("unterminated %s", what)
 			}
+			return err
 		}
+		s.buf = append(s.buf, b)
 	}
 	return nil
 }
 
-func checkCRLF(data []byte, nl int) error {
-	if nl < 1 || data[nl-1] != '\r' {
-		return fmt.Errorf("error finding CRLF near %q", data[nl-1])
+// readUntil appends bytes to s.buf up to but not including any of the
+// bytes in delims, or up to the end of the input.
+func (s *scanner) readUntil(delims string) error {
+	for {
+		b, err := s.peek()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if strings.IndexByte(delims, b) != -1 {
+			return nil
+		}
+		s.readByte()
+		s.buf = append(s.buf, b)
 	}
-	return nil
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
 }
 
 func (s *scanner) next() (tok Token, data []byte, err error) {
-	if len(s.cur) < 1024 {
-		if err = s.fill(); err != nil {
-			return
-		}
-	}
-	if len(s.cur) == 0 {
-		err = io.EOF
+	s.tokOff = s.off
+	s.buf = s.buf[:0]
+
+	c, err := s.peek()
+	if err != nil {
 		return
 	}
 
 	if !s.inBody {
-		// Scan headers.
-		end := bytes.Index(s.cur, []byte{'\n'})
-		if end == -1 {
-			err = fmt.Errorf("error parsing header near %q", s.cur)
+		// OFX 2.x has no header block; its header is an XML processing
+		// instruction in the body.  Some exporters also omit the blank
+		// line between the header block and the body.
+		if c == '<' {
+			s.inBody = true
+		} else if isSpace(c) && c != '\r' && c != '\n' {
+			s.readByte()
+			tok = tWhitespace
 			return
 		}
-		if err = checkCRLF(s.cur, end); err != nil {
+	}
+
+	if !s.inBody {
+		// Scan a header line.
+		if err = s.readUntil("\r\n"); err != nil {
 			return
 		}
+		if c, _ := s.peek(); c == '\r' {
+			s.readByte()
+		}
+		if c, _ := s.peek(); c == '\n' {
+			s.readByte()
+		}
 		tok = tHeader
-		data = s.cur[0 : end-1]
-		s.cur = s.cur[end+1:]
+		data = s.buf
 		if len(data) == 0 {
 			s.inBody = true
 		}
 		return
 	}
 
-	switch s.cur[0] {
-	case '<':
-		end := bytes.Index(s.cur, []byte{'>'})
-		if end == -1 {
-			err = fmt.Errorf("error parsing tag near %q", s.cur)
+	switch {
+	case c == '<':
+		s.readByte()
+		s.buf = append(s.buf, c)
+		if c, _ := s.peek(); c == '?' {
+			if err = s.readThrough("?>", "processing instruction"); err != nil {
+				return
+			}
+			tok = tProcInst
+			data = s.buf[2 : len(s.buf)-2]
+			return
+		}
+		if err = s.readThrough(">", "tag"); err != nil {
+			return
+		}
+		if bytes.HasPrefix(s.buf, []byte("<!--")) {
+			if err = s.readThrough("-->", "comment"); err != nil {
+				return
+			}
+			tok = tWhitespace
 			return
 		}
-		if s.cur[1] == '/' {
+		data = bytes.TrimSpace(s.buf[1 : len(s.buf)-1])
+		if len(data) > 0 && data[0] == '/' {
 			tok = tCloseTag
-			data = s.cur[2:end]
+			data = data[1:]
 		} else {
 			tok = tOpenTag
-			data = s.cur[1:end]
 		}
-		s.cur = s.cur[end+1:]
+		if len(data) == 0 {
+			err = s.errorf("empty tag")
+		}
 		return
-	case '\r':
-		if err = checkCRLF(s.cur, 1); err != nil {
-			return
+	case isSpace(c):
+		for isSpace(c) {
+			s.readByte()
+			if c, err = s.peek(); err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				break
+			}
 		}
 		tok = tWhitespace
-		data = s.cur[0:0]
-		s.cur = s.cur[2:]
 		return
 	default:
-		end := bytes.Index(s.cur, []byte{'\n'})
-		if end == -1 {
-			err = fmt.Errorf("error finding end of line near %q", s.cur)
-			return
-		}
-		if err = checkCRLF(s.cur, end); err != nil {
+		// Text runs to the end of the line in OFX 1.x, or to the close
+		// tag in OFX 2.x.
+		if err = s.readUntil("<\r\n"); err != nil {
 			return
 		}
 		tok = tText
-		data = s.cur[0 : end-1]
-		s.cur = s.cur[end+1:]
+		data = s.buf
 		return
 	}
 }
 
+// Statement describes the statement response that the most recently
+// read entries came from.
+type Statement struct {
+	// Kind is "BANK" for a bank statement or "CREDITCARD" for a credit
+	// card statement.
+	Kind string
+
+	// Currency is the default currency of the statement.
+	// Sample value: "USD".
+	Currency string
+
+	// BankID is the routing number of the bank, if present.
+	BankID string
+
+	// AccountID is the account number.
+	AccountID string
+
+	// AccountType is the type of a bank account, e.g. "CHECKING".
+	AccountType string
+
+	// Balance is the ledger balance, in cents, as of BalanceDate.
+	// Balances come after the transactions in the file, so these are
+	// only filled in once all of a statement's entries are read.
+	Balance     int
+	BalanceDate time.Time
+}
+
 type Reader struct {
 	s scanner
+
+	// stack holds the names of the currently open elements.
+	stack []string
+	// stmt is the statement currently being read, if any.
+	stmt *Statement
+	// trn holds the fields of the transaction currently being read,
+	// keyed by their path relative to the STMTTRN element.
+	trn map[string]string
+	// trnDepth is the depth of the STMTTRN element in stack.
+	trnDepth int
+	// leaf is true if the top of stack is an element that contained
+	// text.  In OFX 1.x such elements have no close tag.
+	leaf bool
+
+	// pending is a token read by ReadHeader that belongs to the body.
+	pending *token
+}
+
+type token struct {
+	tok  Token
+	data []byte
 }
 
 type Header map[string]string
 
 func NewReader(r io.Reader) *Reader {
 	rd := &Reader{}
-	rd.s.r = r
-	rd.s.cur = rd.s.buf[0:0]
+	rd.s.r = bufio.NewReader(charset.NewReader(r, ""))
 	return rd
 }
 
+func (r *Reader) next() (Token, []byte, error) {
+	if t := r.pending; t != nil {
+		r.pending = nil
+		return t.tok, t.data, nil
+	}
+	return r.s.next()
+}
+
+// ReadHeader reads the header that precedes the OFX body.  It must be
+// called first.  If the input has no header, ReadHeader returns an
+// empty Header.
+//
+// OFX 1.x files start with a block of "KEY:value" lines; OFX 2.x files
+// are XML with the same keys as attributes of an <?OFX ...?>
+// processing instruction.  Either way the keys are returned as-is, so
+// e.g. VERSION is "102" for OFX 1.x and "211" for OFX 2.x.
 func (r *Reader) ReadHeader() (Header, error) {
 	h := make(Header)
 	for {
@@ -167,61 +305,237 @@ func (r *Reader) ReadHeader() (Header, error) {
 		if err != nil {
 			return nil, err
 		}
-		if tok != tHeader {
-			return nil, fmt.Errorf("unexpected content near %q", r.s.cur)
+		switch tok {
+		case tHeader:
+			if len(data) == 0 {
+				if len(h) == 0 {
+					// Blank line before the header.
+					r.s.inBody = false
+					continue
+				}
+				return h, nil
+			}
+			parts := bytes.SplitN(data, []byte{':'}, 2)
+			if len(parts) != 2 {
+				return nil, r.s.errorf("bad header line: %q", data)
+			}
+			h[string(parts[0])] = string(parts[1])
+		case tWhitespace:
+			continue
+		case tProcInst:
+			parseProcInst(string(data), h)
+		default:
+			// The body has started, possibly with no header at all.
+			r.pending = &token{tok, bytes.Clone(data)}
+			return h, nil
+		}
+	}
+}
+
+// parseProcInst adds the attributes of an OFX 2.x processing
+// instruction, e.g. `OFX OFXHEADER="200" VERSION="211"`, to h.
+func parseProcInst(pi string, h Header) {
+	target, attrs, _ := strings.Cut(pi, " ")
+	for _, f := range strings.Fields(attrs) {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
 		}
-		if len(data) == 0 {
-			break
+		key = strings.ToUpper(key)
+		value = strings.Trim(value, `"'`)
+		switch target {
+		case "OFX":
+			h[key] = value
+		case "xml":
+			if key == "ENCODING" {
+				h[key] = strings.ToUpper(value)
+			}
 		}
+	}
+}
 
-		parts := bytes.SplitN(data, []byte{':'}, 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("bad header line: %q", data)
+// Statement returns the statement containing the most recently read
+// entry, or nil if no statement has been seen yet.
+func (r *Reader) Statement() *Statement {
+	return r.stmt
+}
+
+func (r *Reader) inside(name string) bool {
+	for _, s := range r.stack {
+		if s == name {
+			return true
 		}
-		h[string(parts[0])] = string(parts[1])
 	}
-	return h, nil
+	return false
 }
 
-func strIndent(indent int) (str string) {
-	for i := 0; i < indent; i++ {
-		str = str + "  "
+// pop closes the innermost open element with the given name, along
+// with any elements opened after it.  SGML OFX omits the close tags of
+// leaf elements, so a close tag can implicitly close several elements.
+func (r *Reader) pop(name string) error {
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		if r.stack[i] == name {
+			r.stack = r.stack[:i]
+			return nil
+		}
 	}
-	return
+	return r.s.errorf("close tag %q with no open tag, stack %v", name, r.stack)
 }
 
-func (r *Reader) Read() {
-	var stack []string
+// setStatementField records a leaf value that isn't part of a
+// transaction but describes the statement.
+func (r *Reader) setStatementField(name, value string) error {
+	if r.stmt == nil {
+		return nil
+	}
+	switch name {
+	case "CURDEF":
+		r.stmt.Currency = value
+	case "BANKID":
+		r.stmt.BankID = value
+	case "ACCTID":
+		if r.inside("BANKACCTFROM") || r.inside("CCACCTFROM") {
+			r.stmt.AccountID = value
+		}
+	case "ACCTTYPE":
+		if r.inside("BANKACCTFROM") {
+			r.stmt.AccountType = value
+		}
+	case "BALAMT":
+		if r.inside("LEDGERBAL") {
+			amount, err := money.ParseDecimal(value)
+			if err != nil {
+				return err
+			}
+			r.stmt.Balance = amount
+		}
+	case "DTASOF":
+		if r.inside("LEDGERBAL") {
+			t, err := parseDate(value)
+			if err != nil {
+				return err
+			}
+			r.stmt.BalanceDate = t
+		}
+	}
+	return nil
+}
+
+// parseDate parses an OFX datetime, e.g. "20120131120000.000[-5:EST]".
+// Only the date part is kept.
+func parseDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("bad date %q", s)
+	}
+	return time.Parse("20060102", s[:8])
+}
+
+// entry converts the fields of a STMTTRN element into an Entry.
+func (r *Reader) entry() (*qif.Entry, error) {
+	e := &qif.Entry{Cleared: qif.Cleared}
+	var err error
+	e.Date, err = parseDate(r.trn["DTPOSTED"])
+	if err != nil {
+		return nil, r.s.errorf("STMTTRN DTPOSTED: %v", err)
+	}
+	e.Amount, err = money.ParseDecimal(r.trn["TRNAMT"])
+	if err != nil {
+		return nil, r.s.errorf("STMTTRN TRNAMT: %v", err)
+	}
+	e.Number = r.trn["FITID"]
+	if r.stmt != nil {
+		e.Currency = r.stmt.Currency
+	}
+	if c := r.trn["CURRENCY.CURSYM"]; c != "" {
+		// The amount is in a currency other than the statement's.
+		e.Currency = c
+	}
+	e.Payee = r.trn["NAME"]
+	if e.Payee == "" {
+		e.Payee = r.trn["PAYEE.NAME"]
+	}
+	e.Memo = r.trn["MEMO"]
+	e.Type = r.trn["TRNTYPE"]
+	return e, nil
+}
 
+// closeLeaf pops the element that contained the most recent text, if
+// it is still open.
+func (r *Reader) closeLeaf() {
+	if r.leaf {
+		r.stack = r.stack[:len(r.stack)-1]
+		r.leaf = false
+	}
+}
+
+// ReadEntry reads the next transaction from a bank or credit card
+// statement, and can be called repeatedly.  ReadHeader must be called
+// first.  Returns (nil, io.EOF) at the end of the input.
+func (r *Reader) ReadEntry() (*qif.Entry, error) {
 	for {
-		tok, data, err := r.s.next()
+		tok, data, err := r.next()
 		if err != nil {
-			if err == io.EOF {
-				break
+			if err == io.EOF && r.trn != nil {
+				return nil, r.s.errorf("%v in STMTTRN", io.ErrUnexpectedEOF)
 			}
-			panic(err)
+			return nil, err
 		}
 		switch tok {
-		case tWhitespace:
+		case tWhitespace, tProcInst:
 			continue
 		case tOpenTag:
-			stack = append(stack, string(data))
-			fmt.Printf("<%s>", data)
+			r.closeLeaf()
+			name := string(data)
+			switch name {
+			case "STMTRS":
+				if r.inside("BANKMSGSRSV1") {
+					r.stmt = &Statement{Kind: "BANK"}
+				}
+			case "CCSTMTRS":
+				if r.inside("CREDITCARDMSGSRSV1") {
+					r.stmt = &Statement{Kind: "CREDITCARD"}
+				}
+			case "STMTTRN":
+				if r.inside("STMTRS") || r.inside("CCSTMTRS") {
+					r.trn = map[string]string{}
+					r.trnDepth = len(r.stack)
+				}
+			}
+			r.stack = append(r.stack, name)
 		case tCloseTag:
-			top := stack[len(stack)-1]
-			if string(data) != top {
-				log.Printf("stack mismatch: got %q, stack %v", data, stack)
+			name := string(data)
+			if r.leaf && r.stack[len(r.stack)-1] == name {
+				// OFX 2.x close tag for a leaf.
+				r.closeLeaf()
+				continue
+			}
+			r.closeLeaf()
+			if err := r.pop(name); err != nil {
+				return nil, err
+			}
+			if name == "STMTTRN" && r.trn != nil {
+				e, err := r.entry()
+				r.trn = nil
+				if err != nil {
+					return nil, err
+				}
+				return e, nil
 			}
-			stack = stack[0 : len(stack)-1]
-			fmt.Printf("</%s>", data)
 		case tText:
-			top := stack[len(stack)-1]
-			fmt.Printf("%s</%s>", strings.Replace(string(data), "&", "&amp;", -1), top)
-			log.Printf("scanned text: %q -> %q", top, data)
-			stack = stack[0 : len(stack)-1]
+			if len(r.stack) == 0 || r.leaf {
+				return nil, r.s.errorf("unexpected text %q", data)
+			}
+			leaf := r.stack[len(r.stack)-1]
+			value := html.UnescapeString(strings.TrimSpace(string(data)))
+			if r.trn != nil {
+				path := strings.Join(r.stack[r.trnDepth+1:], ".")
+				r.trn[path] = value
+			} else if err := r.setStatementField(leaf, value); err != nil {
+				return nil, r.s.errorf("%s: %v", leaf, err)
+			}
+			r.leaf = true
 		default:
-			panic("unexpected tok")
+			return nil, r.s.errorf("unexpected token %v", tok)
 		}
 	}
-	log.Printf("done")
 }
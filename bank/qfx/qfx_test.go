@@ -0,0 +1,357 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qfx
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+func date(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+func crlf(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+const sampleBank = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<DTSERVER>20130102120000[-8:PST]
+<LANGUAGE>ENG
+</SONRS>
+</SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>1
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<STMTRS>
+<CURDEF>USD
+<BANKACCTFROM>
+<BANKID>121000248
+<ACCTID>1234567890
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20121101
+<DTEND>20121231
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20121119120000[0:GMT]
+<TRNAMT>-3.14
+<FITID>201211191
+<NAME>WELLS FARGO BN 11/19 #00163
+<MEMO>WITHDRWL SFO/TERM-2
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CHECK
+<DTPOSTED>20121231
+<TRNAMT>-1592.65
+<FITID>201212311
+<CHECKNUM>1001
+<PAYEE>
+<NAME>CITY OF PORTLAND
+<ADDR1>1221 SW 4TH AVE
+</PAYEE>
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>1000.01
+<DTASOF>20121231
+</LEDGERBAL>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const sampleCard = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<CREDITCARDMSGSRSV1>
+<CCSTMTTRNRS>
+<TRNUID>1
+<CCSTMTRS>
+<CURDEF>USD
+<CCACCTFROM>
+<ACCTID>4111111111111111
+</CCACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20130105
+<TRNAMT>250.00
+<FITID>X1
+<NAME>PAYMENT THANK YOU
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20130106
+<TRNAMT>-10.000
+<FITID>X2
+<NAME>CAFE DE FLORE
+<CURRENCY>
+<CURRATE>1.3
+<CURSYM>EUR
+</CURRENCY>
+</STMTTRN>
+</BANKTRANLIST>
+</CCSTMTRS>
+</CCSTMTTRNRS>
+</CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func readAll(t *testing.T, r *Reader) []qif.Entry {
+	t.Helper()
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("failed header read: %v", err)
+	}
+	var entries []qif.Entry
+	for {
+		e, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed entry read: %v", err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+func checkEntries(t *testing.T, got, want []qif.Entry) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("%d: got\n%#v\nwant\n%#v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBank(t *testing.T) {
+	r := NewReader(strings.NewReader(crlf(sampleBank)))
+	entries := readAll(t, r)
+	checkEntries(t, entries, []qif.Entry{
+		{Number: "201211191", Date: date(2012, 11, 19), Amount: -314, Currency: "USD", Payee: "WELLS FARGO BN 11/19 #00163",
+			Cleared: qif.Cleared, Memo: "WITHDRWL SFO/TERM-2", Type: "DEBIT"},
+		{Number: "201212311", Date: date(2012, 12, 31), Amount: -159265, Currency: "USD", Payee: "CITY OF PORTLAND",
+			Cleared: qif.Cleared, Type: "CHECK"},
+	})
+
+	want := Statement{
+		Kind:        "BANK",
+		Currency:    "USD",
+		BankID:      "121000248",
+		AccountID:   "1234567890",
+		AccountType: "CHECKING",
+		Balance:     100001,
+		BalanceDate: date(2012, 12, 31),
+	}
+	if got := *r.Statement(); got != want {
+		t.Errorf("statement: got\n%#v\nwant\n%#v", got, want)
+	}
+	summary := []string{"1234567890: balance 2012/12/31 1000.01"}
+	if got := r.Summary(); !reflect.DeepEqual(got, summary) {
+		t.Errorf("summary: got %q, want %q", got, summary)
+	}
+}
+
+func TestCreditCard(t *testing.T) {
+	r := NewReader(strings.NewReader(crlf(sampleCard)))
+	entries := readAll(t, r)
+	checkEntries(t, entries, []qif.Entry{
+		{Number: "X1", Date: date(2013, 1, 5), Amount: 25000, Currency: "USD", Payee: "PAYMENT THANK YOU",
+			Cleared: qif.Cleared, Type: "CREDIT"},
+		{Number: "X2", Date: date(2013, 1, 6), Amount: -1000, Currency: "EUR", Payee: "CAFE DE FLORE",
+			Cleared: qif.Cleared, Type: "DEBIT"},
+	})
+	if stmt := r.Statement(); stmt.Kind != "CREDITCARD" || stmt.AccountID != "4111111111111111" {
+		t.Errorf("statement: got %#v", stmt)
+	}
+}
+
+// European banks write amounts with a decimal comma, which OFX allows.
+func TestDecimalComma(t *testing.T) {
+	input := strings.NewReplacer("<TRNAMT>-3.14", "<TRNAMT>-3,14", "<BALAMT>1000.01", "<BALAMT>1000,01").Replace(sampleBank)
+	r := NewReader(strings.NewReader(crlf(input)))
+	entries := readAll(t, r)
+	if len(entries) != 2 || entries[0].Amount != -314 {
+		t.Errorf("got entries %#v", entries)
+	}
+	if got := r.Statement().Balance; got != 100001 {
+		t.Errorf("balance: got %d, want 100001", got)
+	}
+}
+
+const sampleXML = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+  <!-- generated by the bank -->
+  <BANKMSGSRSV1>
+    <STMTTRNRS>
+      <TRNUID>1</TRNUID>
+      <STMTRS>
+        <CURDEF>USD</CURDEF>
+        <BANKACCTFROM>
+          <BANKID>121000248</BANKID>
+          <ACCTID>1234567890</ACCTID>
+          <ACCTTYPE>SAVINGS</ACCTTYPE>
+        </BANKACCTFROM>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20210304000000.000[-5:EST]</DTPOSTED>
+            <TRNAMT>-42.00</TRNAMT>
+            <FITID>A1</FITID>
+            <NAME>AT&amp;T</NAME>
+            <MEMO></MEMO>
+          </STMTTRN>
+        </BANKTRANLIST>
+        <LEDGERBAL>
+          <BALAMT>12.50</BALAMT>
+          <DTASOF>20210331</DTASOF>
+        </LEDGERBAL>
+      </STMTRS>
+    </STMTTRNRS>
+  </BANKMSGSRSV1>
+</OFX>
+`
+
+func TestXML(t *testing.T) {
+	r := NewReader(strings.NewReader(sampleXML))
+	h, err := r.ReadHeader()
+	if err != nil {
+		t.Fatalf("failed header read: %v", err)
+	}
+	if h["VERSION"] != "211" || h["ENCODING"] != "UTF-8" {
+		t.Errorf("header: got %v", h)
+	}
+
+	var entries []qif.Entry
+	for {
+		e, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed entry read: %v", err)
+		}
+		entries = append(entries, *e)
+	}
+	checkEntries(t, entries, []qif.Entry{
+		{Number: "A1", Date: date(2021, 3, 4), Amount: -4200, Currency: "USD", Payee: "AT&T",
+			Cleared: qif.Cleared, Type: "DEBIT"},
+	})
+	if stmt := r.Statement(); stmt.AccountType != "SAVINGS" || stmt.Balance != 1250 {
+		t.Errorf("statement: got %#v", stmt)
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	// LF-only line endings.
+	entries := readAll(t, NewReader(strings.NewReader(sampleBank)))
+	if len(entries) != 2 {
+		t.Errorf("LF: got %d entries, want 2", len(entries))
+	}
+
+	// The whole body on one line.
+	header, body, _ := strings.Cut(sampleBank, "\n\n")
+	oneLine := crlf(header+"\n\n") + strings.ReplaceAll(body, "\n", "")
+	entries = readAll(t, NewReader(strings.NewReader(oneLine)))
+	if len(entries) != 2 || entries[1].Payee != "CITY OF PORTLAND" {
+		t.Errorf("one line: got %#v", entries)
+	}
+
+	// No header at all.
+	entries = readAll(t, NewReader(strings.NewReader(body)))
+	if len(entries) != 2 {
+		t.Errorf("no header: got %d entries, want 2", len(entries))
+	}
+}
+
+func TestLarge(t *testing.T) {
+	const n = 50000
+	var b strings.Builder
+	b.WriteString("OFXHEADER:100\n\n<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20200101<TRNAMT>-1.%02d<FITID>%d<NAME>%s</STMTTRN>\n",
+			i%100, i, strings.Repeat("X", 32))
+	}
+	b.WriteString("</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\n")
+
+	entries := readAll(t, NewReader(strings.NewReader(b.String())))
+	if len(entries) != n {
+		t.Fatalf("got %d entries, want %d", len(entries), n)
+	}
+	if e := entries[n-1]; e.Number != fmt.Sprint(n-1) || e.Amount != -199 {
+		t.Errorf("last entry: got %#v", e)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"OFXHEADER:100\n\n<OFX>\n</STMTTRN>\n", "offset 21: close tag"},
+		{"OFXHEADER:100\n\n<OFX><BANKMSGSRSV1><STMTRS><STMTTRN><NAME", "offset 51: unterminated tag"},
+		{"OFXHEADER:100\n\n<OFX><BANKMSGSRSV1><STMTRS><STMTTRN><NAME>foo\n", "unexpected EOF in STMTTRN"},
+		{"OFXHEADER:100\n\n<OFX><BANKMSGSRSV1><STMTRS><STMTTRN><DTPOSTED>20200101<TRNAMT>x</STMTTRN>", "TRNAMT"},
+	}
+	for _, test := range tests {
+		r := NewReader(strings.NewReader(test.input))
+		if _, err := r.ReadHeader(); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		for err == nil {
+			_, err = r.ReadEntry()
+		}
+		if err == io.EOF || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: got error %v, want %q", test.input, err, test.err)
+		}
+	}
+}
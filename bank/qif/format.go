@@ -0,0 +1,55 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qif
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/evmar/fin/bank"
+)
+
+func init() {
+	bank.Register(&bank.Format{
+		Name:        "qif",
+		Description: "Quicken Interchange Format",
+		Extensions:  []string{".qif"},
+		Sniff:       sniff,
+		Open:        open,
+	})
+}
+
+// sniff recognizes QIF by its first line, a header like "!Type:Bank".
+func sniff(prefix []byte) bool {
+	line, _, _ := bytes.Cut(bytes.TrimSpace(prefix), []byte("\n"))
+	line = bytes.ToLower(bytes.TrimSpace(line))
+	for _, h := range []string{"!type:", "!account", "!option:", "!clear:"} {
+		if bytes.HasPrefix(line, []byte(h)) {
+			return true
+		}
+	}
+	return false
+}
+
+func open(r io.Reader, opts *bank.Options) (bank.Reader, error) {
+	qr := NewReader(r)
+	if opts.DateOrder != nil {
+		qr.DateOrder = *opts.DateOrder
+	}
+	if _, err := qr.ReadHeader(); err != nil {
+		return nil, err
+	}
+	return qr, nil
+}
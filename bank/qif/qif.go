@@ -22,68 +22,137 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"strconv"
 	"strings"
-	"time"
+
+	"github.com/evmar/fin/bank"
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/dates"
+	"github.com/evmar/fin/bank/money"
 )
 
-// ClearedType represents the "cleared" state of a transaction.
-type ClearedType int
+// The entry types are shared by all of the bank readers, so they're
+// defined in package bank.  These aliases keep the names QIF gives them.
+type (
+	ClearedType = bank.ClearedType
+	Entry       = bank.Entry
+	Split       = bank.Split
+)
 
 const (
-	NotCleared ClearedType = iota
-	Cleared
-	Reconciled
+	NotCleared = bank.NotCleared
+	Cleared    = bank.Cleared
+	Reconciled = bank.Reconciled
 )
 
-// Entry represents a single entry in the ledger.
-type Entry struct {
-	// Number is a string identifier for the transaction.
-	// Sample value: "0224169143028400738398".
-	Number string
-
-	// Date is the date of the transaction.
-	Date time.Time
+// Account is an account header from an "!Account" section.
+type Account struct {
+	// Name is the name of the account.
+	// Sample value: "Checking".
+	Name string
 
-	// Amount is the amount of money, in cents.
-	// Amount will be negative for withdrawals.
-	Amount int
+	// Type is the type of the account, using the same names as
+	// transaction sections.  Sample value: "CCard".
+	Type string
 
-	// Payee is the recipient of the transaction as reported by the bank.
-	// Sample value: "WHOLEFDS NOE 10379 SAN FRANCISCOCA".
-	Payee string
-
-	// Address is the address of the recipient as reported by the bank.
-	// Sample value: "SAN FRANCISCCA".
-	Address string
+	// Description is a free-form description of the account.
+	Description string
+}
 
-	// Cleared is the status of the transaction, or zero if not present.
-	Cleared ClearedType
+// transactionSections are the "!Type:" sections that contain
+// transactions.  Other sections, such as category lists, are skipped.
+// Investment ("Invst") sections are skipped too: their records use
+// other field codes, e.g. N for the action, and describe trades rather
+// than entries.
+var transactionSections = map[string]bool{
+	"Bank":    true,
+	"Cash":    true,
+	"CCard":   true,
+	"Oth A":   true,
+	"Oth L":   true,
+	"Invoice": true,
 }
 
 type Reader struct {
+	// DateOrder is the order of fields in the input's dates.
+	// It defaults to dates.MDY, the usual order in QIF files.
+	DateOrder dates.Order
+
 	s       *bufio.Scanner
 	lineNum int
+
+	// section is the current section, e.g. "Bank" for "!Type:Bank" or
+	// "Account" for "!Account".
+	section string
+	// autoSwitch is set by "!Option:AutoSwitch", which marks a list of
+	// accounts rather than the header of the account that follows.
+	autoSwitch bool
+	// account is the account that entries currently belong to.
+	account *Account
+	// accounts are all the account headers read so far.
+	accounts []Account
 }
 
-// NewReader constructs a new Reader for a given io.Reader.
+// NewReader constructs a new Reader for a given io.Reader.  Quicken
+// writes Windows-1252, but the charset is detected as other programs may
+// write e.g. UTF-8.
 func NewReader(r io.Reader) *Reader {
-	return &Reader{bufio.NewScanner(r), 0}
+	return &Reader{DateOrder: dates.MDY, s: bufio.NewScanner(charset.NewReader(r, ""))}
 }
 
 func (r *Reader) line() ([]byte, error) {
-	if !r.s.Scan() {
-		if err := r.s.Err(); err != nil {
-			return nil, err
+	for r.s.Scan() {
+		r.lineNum++
+		if line := r.s.Bytes(); len(bytes.TrimSpace(line)) > 0 {
+			return line, nil
 		}
-		return nil, nil
 	}
-	r.lineNum++
-	return r.s.Bytes(), nil
+	return nil, r.s.Err()
 }
 
-// ReadHeader reads the header from the input and returns the value,
-// e.g. "CCard".  It must be called first when reading.
+// header handles a line starting with "!", which switches sections or
+// sets an option, and returns its value without the "Type:" prefix.
+//
+// Exporters differ in how they capitalize headers, e.g. "!Type:BANK",
+// so they are matched ignoring case, and the known section names are
+// returned in their usual spelling.
+func (r *Reader) header(line []byte) (string, error) {
+	h := strings.TrimSpace(string(line[1:]))
+	lower := strings.ToLower(h)
+	switch {
+	case strings.HasPrefix(lower, "type:"):
+		h = sectionName(strings.TrimSpace(h[len("Type:"):]))
+		r.section = h
+	case lower == "account":
+		h = "Account"
+		r.section = h
+	case lower == "option:autoswitch":
+		h = "Option:AutoSwitch"
+		r.autoSwitch = true
+	case lower == "clear:autoswitch":
+		h = "Clear:AutoSwitch"
+		r.autoSwitch = false
+	default:
+		if !strings.HasPrefix(lower, "option:") && !strings.HasPrefix(lower, "clear:") {
+			return "", fmt.Errorf("line %d: bad header %q", r.lineNum, line)
+		}
+	}
+	return h, nil
+}
+
+// sectionName returns the usual spelling of a transaction section's
+// name, e.g. "Bank" for "BANK", or name itself for other sections.
+func sectionName(name string) string {
+	for s := range transactionSections {
+		if strings.EqualFold(s, name) {
+			return s
+		}
+	}
+	return name
+}
+
+// ReadHeader reads the first header line from the input and returns
+// its value, e.g. "CCard" for "!Type:CCard" or "Account" for
+// "!Account".  It must be called first when reading.
 func (r *Reader) ReadHeader() (string, error) {
 	line, err := r.line()
 	if err != nil {
@@ -92,36 +161,78 @@ func (r *Reader) ReadHeader() (string, error) {
 	if line == nil {
 		return "", io.ErrUnexpectedEOF
 	}
-	prefix := []byte("!Type:")
-	if !bytes.HasPrefix(line, prefix) {
+	if line[0] != '!' {
 		return "", fmt.Errorf("bad header")
 	}
-	id := line[len(prefix):]
-	return string(id), nil
+	return r.header(line)
 }
 
-func isoToUTF(data []byte) string {
-	ascii := true
-	for _, b := range data {
-		if b > 0x7f {
-			ascii = false
-			break
+// Section returns the current section, e.g. "Bank" or "Account".
+func (r *Reader) Section() string {
+	return r.section
+}
+
+// Accounts returns the account headers read so far.
+func (r *Reader) Accounts() []Account {
+	return r.accounts
+}
+
+// readAccount reads the rest of an account header record.
+func (r *Reader) readAccount(line []byte) error {
+	a := Account{}
+	for ; line != nil; line, _ = r.line() {
+		data := string(line[1:])
+		switch line[0] {
+		case 'N':
+			a.Name = data
+		case 'T':
+			a.Type = data
+		case 'D':
+			a.Description = data
+		case '^':
+			r.accounts = append(r.accounts, a)
+			if !r.autoSwitch {
+				r.account = &a
+			}
+			return nil
 		}
 	}
-	if ascii {
-		return string(data)
+	if err := r.s.Err(); err != nil {
+		return err
 	}
+	return fmt.Errorf("line %d: %w", r.lineNum, io.ErrUnexpectedEOF)
+}
 
-	buf := &bytes.Buffer{}
-	for _, b := range data {
-		buf.WriteRune(rune(b))
+// skipRecord reads the rest of a record in a section that has no
+// transactions.
+func (r *Reader) skipRecord(line []byte) error {
+	for ; line != nil; line, _ = r.line() {
+		if line[0] == '^' {
+			return nil
+		}
 	}
-	return buf.String()
+	if err := r.s.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("line %d: %w", r.lineNum, io.ErrUnexpectedEOF)
+}
+
+// lastSplit returns the split that split fields apply to.  A split
+// starts with its 'S' line, but if that is missing the fields start a
+// new uncategorized split.
+func lastSplit(e *Entry) *Split {
+	if len(e.Splits) == 0 {
+		e.Splits = append(e.Splits, Split{})
+	}
+	return &e.Splits[len(e.Splits)-1]
 }
 
 // ReadEntry reads an Entry from the input, and can be called repeatedly.
 // ReadHeader must be called first.  Returns (nil, io.EOF) at the end
 // of the input.
+//
+// Account headers and sections without transactions are consumed
+// along the way; see Section and Accounts.
 func (r *Reader) ReadEntry() (*Entry, error) {
 	e := &Entry{}
 	read := false
@@ -133,8 +244,31 @@ func (r *Reader) ReadEntry() (*Entry, error) {
 		if line == nil {
 			break
 		}
+		if !read {
+			if line[0] == '!' {
+				if _, err := r.header(line); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if r.section == "Account" {
+				if err := r.readAccount(line); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if !transactionSections[r.section] {
+				if err := r.skipRecord(line); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if r.account != nil {
+				e.Account = r.account.Name
+			}
+		}
 		code := line[0]
-		data := isoToUTF(line[1:])
+		data := string(line[1:])
 		switch code {
 		case 'A':
 			e.Address = data
@@ -150,22 +284,41 @@ func (r *Reader) ReadEntry() (*Entry, error) {
 				log.Printf("qif: unknown cleared status %q", data)
 			}
 		case 'D':
-			t, err := time.Parse("01/02/2006", data)
+			t, err := dates.Parse(data, r.DateOrder)
 			if err != nil {
 				return nil, fmt.Errorf("line %d: %w", r.lineNum, err)
 			}
 			e.Date = t
+		case 'L':
+			e.Category = data
+		case 'M':
+			e.Memo = data
 		case 'N':
 			e.Number = data
 		case 'P':
 			e.Payee = data
 		case 'T':
-			data = strings.ReplaceAll(data, ",", "")
-			f, err := strconv.ParseFloat(data, 32)
+			amount, err := money.Parse(data)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", r.lineNum, err)
+			}
+			e.Amount = amount
+		case 'S':
+			e.Splits = append(e.Splits, Split{Category: data})
+		case 'E':
+			split := lastSplit(e)
+			split.Memo = data
+		case '$':
+			amount, err := money.Parse(data)
 			if err != nil {
 				return nil, fmt.Errorf("line %d: %w", r.lineNum, err)
 			}
-			e.Amount = int(f * 100)
+			split := lastSplit(e)
+			split.Amount = amount
+		case '%':
+			// Percentage of a split; the '$' line has the amount.
+		case '!':
+			return nil, fmt.Errorf("line %d: header %q inside a record", r.lineNum, line)
 		case '^':
 			if read {
 				return e, nil
@@ -18,8 +18,11 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/evmar/fin/bank/dates"
 )
 
 const sampleFile = `!Type:Bank
@@ -32,6 +35,8 @@ D12/31/2012
 PCHECKCARD 1117 CITY OF PORTLAND DEPT T PORTLAND OR 2443232690
 T-1,592.65
 C*
+MParking ticket
+LAuto:Fines
 ^
 `
 
@@ -61,23 +66,25 @@ func TestParse(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed entry read: %#v", err)
 	}
-	if *e != exp {
+	if !reflect.DeepEqual(*e, exp) {
 		t.Fatalf("expected %#v, got %#v", exp, e)
 	}
 
 	exp = Entry{
-		Number:  "",
-		Date:    date(2012, 12, 31),
-		Amount:  -159265,
-		Payee:   "CHECKCARD 1117 CITY OF PORTLAND DEPT T PORTLAND OR 2443232690",
-		Address: "",
-		Cleared: Cleared,
+		Number:   "",
+		Date:     date(2012, 12, 31),
+		Amount:   -159265,
+		Payee:    "CHECKCARD 1117 CITY OF PORTLAND DEPT T PORTLAND OR 2443232690",
+		Address:  "",
+		Cleared:  Cleared,
+		Memo:     "Parking ticket",
+		Category: "Auto:Fines",
 	}
 	e, err = r.ReadEntry()
 	if err != nil {
 		t.Fatalf("failed entry read: %#v", err)
 	}
-	if *e != exp {
+	if !reflect.DeepEqual(*e, exp) {
 		t.Fatalf("expected %#v, got %#v", exp, e)
 	}
 
@@ -105,3 +112,212 @@ func TestTrunc(t *testing.T) {
 		}
 	}
 }
+
+func TestSplits(t *testing.T) {
+	const input = `!Type:Bank
+D01/15/2013
+PACME CORP PAYROLL
+T2,500.00
+SSalary
+$4,000.00
+STaxes:Federal
+EWithholding
+$-1,000.00
+S401k
+$-500.00
+^
+`
+	r := NewReader(bytes.NewBufferString(input))
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("failed header read: %#v", err)
+	}
+	e, err := r.ReadEntry()
+	if err != nil {
+		t.Fatalf("failed entry read: %#v", err)
+	}
+	exp := []Split{
+		{Category: "Salary", Amount: 400000},
+		{Category: "Taxes:Federal", Memo: "Withholding", Amount: -100000},
+		{Category: "401k", Amount: -50000},
+	}
+	if e.Amount != 250000 || !reflect.DeepEqual(e.Splits, exp) {
+		t.Fatalf("expected %#v, got %#v", exp, e)
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	const input = `!Option:AutoSwitch
+!Account
+NChecking
+TBank
+^
+NVisa
+TCCard
+DTravel card
+^
+!Clear:AutoSwitch
+!Type:Cat
+NFood
+E
+^
+NFood:Restaurants
+E
+^
+!Type:Class
+NBusiness
+^
+!Account
+NChecking
+TBank
+^
+!Type:Bank
+D01/02/2013
+PDEPOSIT
+T100.00
+^
+
+!Account
+NBrokerage
+TInvst
+^
+!Type:Invst
+D01/02/2013
+NBuy
+YACME
+I12.50
+Q10
+T125.00
+^
+D01/03/2013
+NDiv
+YACME
+T3.00
+^
+!Account
+NVisa
+TCCard
+^
+!Type:CCard
+D01/03/2013
+PCAFE
+T-4.50
+^
+`
+	r := NewReader(bytes.NewBufferString(input))
+	h, err := r.ReadHeader()
+	if err != nil {
+		t.Fatalf("failed header read: %#v", err)
+	}
+	if h != "Option:AutoSwitch" {
+		t.Fatalf("expected %q, got %q", "Option:AutoSwitch", h)
+	}
+
+	var got []string
+	for {
+		e, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed entry read: %#v", err)
+		}
+		got = append(got, e.Account+" "+e.Payee)
+	}
+	exp := []string{"Checking DEPOSIT", "Visa CAFE"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %q, got %q", exp, got)
+	}
+	if r.Section() != "CCard" {
+		t.Errorf("expected section %q, got %q", "CCard", r.Section())
+	}
+	if accounts := r.Accounts(); len(accounts) != 5 || accounts[1].Description != "Travel card" {
+		t.Errorf("unexpected accounts %#v", accounts)
+	}
+}
+
+func TestHeaderCase(t *testing.T) {
+	for _, header := range []string{"!Type:BANK", "!type:Bank", "!TYPE:bank"} {
+		input := header + "\nD01/02/2013\nPDEPOSIT\nT100.00\n^\n"
+		r := NewReader(bytes.NewBufferString(input))
+		tag, err := r.ReadHeader()
+		if err != nil {
+			t.Errorf("%s: failed header read: %v", header, err)
+			continue
+		}
+		if tag != "Bank" {
+			t.Errorf("%s: expected %q, got %q", header, "Bank", tag)
+		}
+		e, err := r.ReadEntry()
+		if err != nil {
+			t.Errorf("%s: failed entry read: %v", header, err)
+			continue
+		}
+		if e.Payee != "DEPOSIT" {
+			t.Errorf("%s: got entry %#v", header, e)
+		}
+	}
+
+	r := NewReader(bytes.NewBufferString("!option:autoswitch\n!ACCOUNT\nNChecking\nTBank\n^\n!clear:AUTOSWITCH\n"))
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("failed header read: %v", err)
+	}
+	if _, err := r.ReadEntry(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	if accounts := r.Accounts(); len(accounts) != 1 || accounts[0].Name != "Checking" {
+		t.Errorf("unexpected accounts %#v", accounts)
+	}
+}
+
+func TestDateOrder(t *testing.T) {
+	const input = "!Type:Bank\nD31/01'13\nPFOO\nT1.00\n^\n"
+	r := NewReader(bytes.NewBufferString(input))
+	r.DateOrder = dates.DMY
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("failed header read: %#v", err)
+	}
+	e, err := r.ReadEntry()
+	if err != nil {
+		t.Fatalf("failed entry read: %#v", err)
+	}
+	if !e.Date.Equal(date(2013, 1, 31)) {
+		t.Fatalf("expected 2013-01-31, got %v", e.Date)
+	}
+}
+
+func TestAmounts(t *testing.T) {
+	// These lost a cent when amounts were parsed as floats.
+	const input = "!Type:Bank\nD01/01/2013\nPA\nT-19.99\n^\nD01/01/2013\nPB\nT123,456.78\n^\n"
+	r := NewReader(bytes.NewBufferString(input))
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("failed header read: %#v", err)
+	}
+	for _, exp := range []int{-1999, 12345678} {
+		e, err := r.ReadEntry()
+		if err != nil {
+			t.Fatalf("failed entry read: %#v", err)
+		}
+		if e.Amount != exp {
+			t.Errorf("expected %d, got %d", exp, e.Amount)
+		}
+	}
+}
+
+func TestCharset(t *testing.T) {
+	for _, input := range []string{
+		"!Type:Bank\nD01/02/2013\nPCaf\xe9 Noir\nT-4.50\n^\n",
+		"!Type:Bank\nD01/02/2013\nPCafé Noir\nT-4.50\n^\n",
+	} {
+		r := NewReader(bytes.NewBufferString(input))
+		if _, err := r.ReadHeader(); err != nil {
+			t.Fatal(err)
+		}
+		e, err := r.ReadEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Payee != "Café Noir" {
+			t.Errorf("%q: got payee %q", input, e.Payee)
+		}
+	}
+}
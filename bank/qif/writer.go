@@ -0,0 +1,112 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qif
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/money"
+)
+
+// Writer writes entries as QIF.  Text is written in Windows-1252, as
+// Quicken expects and Reader reads; other characters become '?'.
+type Writer struct {
+	w io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// field formats a field's text: on one line, in Windows-1252.
+func field(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		c, ok := charset.Encode1252(r)
+		if !ok {
+			c = '?'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+// WriteHeader starts a section of transactions, e.g. "!Type:Bank" for
+// the section "Bank".  It must be called before WriteEntry.
+func (w *Writer) WriteHeader(section string) error {
+	_, err := fmt.Fprintf(w.w, "!Type:%s\n", section)
+	return err
+}
+
+// WriteAccount writes an account header.  The entries in the sections
+// written after it belong to the account.
+func (w *Writer) WriteAccount(a *Account) error {
+	var b strings.Builder
+	b.WriteString("!Account\n")
+	fmt.Fprintf(&b, "N%s\n", field(a.Name))
+	if a.Type != "" {
+		fmt.Fprintf(&b, "T%s\n", field(a.Type))
+	}
+	if a.Description != "" {
+		fmt.Fprintf(&b, "D%s\n", field(a.Description))
+	}
+	b.WriteString("^\n")
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
+
+// WriteEntry writes an entry as a record.  The entry's Type, Currency,
+// ValueDate, From, To and Account have no QIF fields and aren't
+// written.
+func (w *Writer) WriteEntry(e *Entry) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "D%s\n", e.Date.Format("01/02/2006"))
+	fmt.Fprintf(&b, "T%s\n", money.Format(e.Amount))
+	switch e.Cleared {
+	case Cleared:
+		b.WriteString("C*\n")
+	case Reconciled:
+		b.WriteString("CX\n")
+	}
+	if e.Number != "" {
+		fmt.Fprintf(&b, "N%s\n", field(e.Number))
+	}
+	if e.Payee != "" {
+		fmt.Fprintf(&b, "P%s\n", field(e.Payee))
+	}
+	if e.Address != "" {
+		fmt.Fprintf(&b, "A%s\n", field(e.Address))
+	}
+	if e.Memo != "" {
+		fmt.Fprintf(&b, "M%s\n", field(e.Memo))
+	}
+	if e.Category != "" {
+		fmt.Fprintf(&b, "L%s\n", field(e.Category))
+	}
+	for _, s := range e.Splits {
+		fmt.Fprintf(&b, "S%s\n", field(s.Category))
+		if s.Memo != "" {
+			fmt.Fprintf(&b, "E%s\n", field(s.Memo))
+		}
+		fmt.Fprintf(&b, "$%s\n", money.Format(s.Amount))
+	}
+	b.WriteString("^\n")
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qif
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	entries := []*Entry{
+		{Number: "1001", Date: date(2013, 1, 2), Amount: -123450, Payee: "Café Müller",
+			Address: "Berlin", Memo: "dinner\nfor two", Category: "Food:Restaurants", Cleared: Cleared, Account: "Checking"},
+		{Date: date(2013, 1, 3), Amount: -6000, Payee: "Market", Cleared: Reconciled, Account: "Checking",
+			Memo: "“fresh” €5 bag",
+			Splits: []Split{
+				{Category: "Food:Groceries", Memo: "veg", Amount: -4000},
+				{Category: "Cash", Amount: -2000},
+			}},
+	}
+
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	if err := w.WriteAccount(&Account{Name: "Checking", Type: "Bank"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHeader("Bank"); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if err := w.WriteEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const expect = "!Account\nNChecking\nTBank\n^\n!Type:Bank\n" +
+		"D01/02/2013\nT-1234.50\nC*\nN1001\nPCaf\xe9 M\xfcller\nABerlin\nMdinner for two\nLFood:Restaurants\n^\n" +
+		"D01/03/2013\nT-60.00\nCX\nPMarket\nM\x93fresh\x94 \x805 bag\nSFood:Groceries\nEveg\n$-40.00\nSCash\n$-20.00\n^\n"
+	if b.String() != expect {
+		t.Errorf("got\n%q\nwant\n%q", b.String(), expect)
+	}
+
+	// What's written reads back, apart from the memo's line break.
+	entries[0].Memo = "dinner for two"
+	r := NewReader(&b)
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatal(err)
+	}
+	for i, expect := range entries {
+		e, err := r.ReadEntry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(e, expect) {
+			t.Errorf("%d: got\n%#v\nwant\n%#v", i, e, expect)
+		}
+	}
+	if _, err := r.ReadEntry(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestWriteUnencodable(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	if err := w.WriteHeader("Bank"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(&Entry{Date: date(2013, 1, 2), Amount: 100, Payee: "北京"}); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "!Type:Bank\nD01/02/2013\nT1.00\nP??\n^\n"; b.String() != expect {
+		t.Errorf("got %q, want %q", b.String(), expect)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Batch records a single run of "fin import".  Every entry inserted by
+// the run refers to its batch, so that a bad import can be undone.
+type Batch struct {
+	ID     int
+	Path   string
+	Hash   string
+	Source string
+	Time   string
+	Count  int
+}
+
+func hashData(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// createBatch inserts a new batch and returns its id.  The count is
+// filled in by finishBatch once the entries are inserted.
+func createBatch(tx *sql.Tx, path, hash, source string) (int, error) {
+	res, err := tx.Exec(`insert into batch (path, hash, source, time, count) values (?, ?, ?, ?, 0)`,
+		path, hash, source, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func finishBatch(tx *sql.Tx, id, count int) error {
+	_, err := tx.Exec(`update batch set count = ? where id = ?`, count, id)
+	return err
+}
+
+// findBatchByHash returns the id of an earlier batch with the same file
+// contents, or 0 if there is none.
+func findBatchByHash(tx *sql.Tx, hash string) (int, error) {
+	var id int
+	err := tx.QueryRow(`select id from batch where hash = ? order by id limit 1`, hash).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+func allBatches(db *sql.DB) ([]*Batch, error) {
+	rows, err := db.Query(`select id, path, hash, source, time, count from batch order by id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var batches []*Batch
+	for rows.Next() {
+		b := &Batch{}
+		if err := rows.Scan(&b.ID, &b.Path, &b.Hash, &b.Source, &b.Time, &b.Count); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+func listBatches(db *sql.DB, w io.Writer) error {
+	batches, err := allBatches(db)
+	if err != nil {
+		return err
+	}
+	for _, b := range batches {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\n", b.ID, b.Time, b.Source, b.Count, b.Path)
+	}
+	return nil
+}
+
+// undoBatch removes a batch along with the entries it inserted and
+// their splits and tags.
+func undoBatch(db *sql.DB, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`delete from batch where id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("no import batch %d", id)
+	}
+
+	if _, err := tx.Exec(`delete from tag where entryid in (select id from entry where batch = ?)`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`delete from splittag where splitid in
+		(select split.id from split join entry on split.entryid = entry.id where entry.batch = ?)`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`delete from split where entryid in (select id from entry where batch = ?)`, id); err != nil {
+		return err
+	}
+	res, err = tx.Exec(`delete from entry where batch = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	fmt.Printf("removed batch %d: %d entries\n", id, n)
+	return nil
+}
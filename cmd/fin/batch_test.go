@@ -0,0 +1,108 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+// addBatch imports entries as a batch of a file with the given contents.
+func addBatch(t *testing.T, db *sql.DB, data string, entries ...*qif.Entry) int {
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	id, err := createBatch(tx, "test.qif", hashData([]byte(data)), "checking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if err := insertEntry(tx, "checking", id, e, &importOptions{categoryTags: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := finishBatch(tx, id, len(entries)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func count(t *testing.T, db *sql.DB, table string) int {
+	var n int
+	if err := db.QueryRow(`select count(*) from ` + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestBatches(t *testing.T) {
+	db := testDB(t)
+	first := addBatch(t, db, "one",
+		&qif.Entry{Date: date(2026, 3, 2), Amount: -450, Payee: "COFFEE", Category: "Food:Coffee"},
+		&qif.Entry{Date: date(2026, 3, 3), Amount: -3000, Payee: "MARKET", Splits: []qif.Split{
+			{Category: "Food", Amount: -2000},
+			{Category: "Household", Amount: -1000},
+		}},
+	)
+	second := addBatch(t, db, "two",
+		&qif.Entry{Date: date(2026, 3, 4), Amount: 10000, Payee: "PAYROLL", Category: "Income"},
+	)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for data, want := range map[string]int{"one": first, "two": second, "three": 0} {
+		if got, err := findBatchByHash(tx, hashData([]byte(data))); err != nil || got != want {
+			t.Errorf("findBatchByHash(%q) = %d, %v; want %d", data, got, err, want)
+		}
+	}
+	tx.Rollback()
+
+	var buf bytes.Buffer
+	if err := listBatches(db, &buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("listBatches: got %q", buf.String())
+	}
+	for i, want := range []string{"\tchecking\t2\ttest.qif", "\tchecking\t1\ttest.qif"} {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("listBatches line %d: got %q, want suffix %q", i, lines[i], want)
+		}
+	}
+
+	if err := undoBatch(db, first); err != nil {
+		t.Fatal(err)
+	}
+	for table, want := range map[string]int{"batch": 1, "entry": 1, "split": 0, "splittag": 0, "tag": 1} {
+		if got := count(t, db, table); got != want {
+			t.Errorf("after undo: %d rows in %s, want %d", got, table, want)
+		}
+	}
+	if err := undoBatch(db, first); err == nil {
+		t.Errorf("undoing a batch twice: expected error")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// sourcesFile is the name of the file that maps the statement files in
+// a directory, and its subdirectories, to sources.  It holds a list of
+// rules like
+//
+//	[
+//	  {"pattern": "chase*.csv", "source": "chase"},
+//	  {"pattern": "*.qif", "source": "checking"}
+//	]
+//
+// where each pattern is matched, ignoring case, against file names as
+// in path.Match.  The first matching rule wins, and the rules of a
+// subdirectory come before those of its parents.
+const sourcesFile = "fin-sources.json"
+
+type sourceRule struct {
+	Pattern string `json:"pattern"`
+	Source  string `json:"source"`
+}
+
+// loadSourceRules reads the sources file in dir of fsys, if there is
+// one.
+func loadSourceRules(fsys fs.FS, dir, display string) ([]sourceRule, error) {
+	data, err := fs.ReadFile(fsys, path.Join(dir, sourcesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var rules []sourceRule
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", display, err)
+	}
+	for _, r := range rules {
+		if _, err := path.Match(r.Pattern, ""); err != nil || r.Source == "" {
+			return nil, fmt.Errorf("%s: bad rule %q -> %q", display, r.Pattern, r.Source)
+		}
+	}
+	return rules, nil
+}
+
+// matchSource returns the source of the first rule matching the file
+// name, or def if none does.
+func matchSource(rules []sourceRule, name, def string) string {
+	name = strings.ToLower(name)
+	for _, r := range rules {
+		if ok, _ := path.Match(strings.ToLower(r.Pattern), name); ok {
+			return r.Source
+		}
+	}
+	return def
+}
+
+// isZip reports whether a path names a zip archive.
+func isZip(p string) bool {
+	return strings.EqualFold(path.Ext(p), ".zip")
+}
+
+// collectInputs returns the files to import from path, which is a
+// statement file, "-" for stdin, a directory, or a zip archive.  Files
+// are imported into the source their directory's sources file gives,
+// or else into name.  bulk is set if path is a directory or archive.
+func collectInputs(path, name string) (inputs []*importInput, bulk bool, err error) {
+	if path != "-" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, false, err
+		}
+		switch {
+		case info.IsDir():
+			inputs, err := walkInputs(os.DirFS(path), path, nil, name)
+			return inputs, true, err
+		case isZip(path):
+			// The sources file beside an archive applies to its
+			// contents.
+			dir := filepath.Dir(path)
+			rules, err := loadSourceRules(os.DirFS(dir), ".", filepath.Join(dir, sourcesFile))
+			if err != nil {
+				return nil, false, err
+			}
+			zr, err := zip.OpenReader(path)
+			if err != nil {
+				return nil, false, err
+			}
+			defer zr.Close()
+			inputs, err := walkInputs(zr, path, rules, name)
+			return inputs, true, err
+		}
+	}
+	if name == "" {
+		return nil, false, fmt.Errorf("%s: importing a file needs a source name", path)
+	}
+	data, err := readInput(path)
+	if err != nil {
+		return nil, false, err
+	}
+	return []*importInput{{path: path, data: data, source: name}}, false, nil
+}
+
+// walkInputs returns the files in fsys, descending into directories and
+// zip archives.  display is how fsys is named in messages, and rules
+// are the sources file rules that apply to its root.
+func walkInputs(fsys fs.FS, display string, rules []sourceRule, name string) ([]*importInput, error) {
+	dirRules := map[string][]sourceRule{}
+	var inputs []*importInput
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip hidden files and the resource forks that macOS adds
+		// to archives.
+		if p != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "__MACOSX") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		shown := filepath.Join(display, filepath.FromSlash(p))
+		if d.IsDir() {
+			inherited := rules
+			if p != "." {
+				inherited = dirRules[path.Dir(p)]
+			}
+			local, err := loadSourceRules(fsys, p, filepath.Join(shown, sourcesFile))
+			if err != nil {
+				return err
+			}
+			dirRules[p] = append(local, inherited...)
+			return nil
+		}
+		if d.Name() == sourcesFile {
+			return nil
+		}
+		rules := dirRules[path.Dir(p)]
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		if isZip(p) {
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				return fmt.Errorf("%s: %w", shown, err)
+			}
+			in, err := walkInputs(zr, shown, rules, name)
+			if err != nil {
+				return err
+			}
+			inputs = append(inputs, in...)
+			return nil
+		}
+		inputs = append(inputs, &importInput{
+			path:   shown,
+			data:   data,
+			source: matchSource(rules, d.Name(), name),
+		})
+		return nil
+	})
+	return inputs, err
+}
+
+// printImportSummary prints a table of what a bulk import did.
+func printImportSummary(w io.Writer, results []*importResult) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tFORMAT\tSOURCE\tNEW\tSKIPPED\tAMBIGUOUS\tBATCH")
+	var total [3]int
+	for _, r := range results {
+		if r.skipped != "" {
+			format := r.format
+			if format == "" {
+				format = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t-\t\t\t\t%s\n", r.path, format, r.skipped)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n", r.path, r.format, r.source,
+			r.counts[statusNew], r.counts[statusSkipped], r.counts[statusAmbiguous], r.batch)
+		for i := range total {
+			total[i] += r.counts[i]
+		}
+	}
+	fmt.Fprintf(tw, "total\t\t\t%d\t%d\t%d\t\n", total[statusNew], total[statusSkipped], total[statusAmbiguous])
+	return tw.Flush()
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMatchSource(t *testing.T) {
+	rules := []sourceRule{
+		{Pattern: "chase*.csv", Source: "chase"},
+		{Pattern: "*.qif", Source: "checking"},
+	}
+	tests := []struct {
+		name, want string
+	}{
+		{"chase-2026-03.csv", "chase"},
+		{"Chase-2026-03.CSV", "chase"},
+		{"export.qif", "checking"},
+		{"chase.qif", "checking"},
+		{"other.csv", "default"},
+	}
+	for _, test := range tests {
+		if got := matchSource(rules, test.name, "default"); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWalkInputs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fin-sources.json": {Data: []byte(`[
+			{"pattern": "*.qif", "source": "checking"},
+			{"pattern": "*.zip", "source": "unused"}
+		]`)},
+		"a.qif":                 {Data: []byte("a")},
+		"notes.txt":             {Data: []byte("n")},
+		".hidden.qif":           {Data: []byte("h")},
+		"card/fin-sources.json": {Data: []byte(`[{"pattern": "*.csv", "source": "card"}]`)},
+		"card/b.csv":            {Data: []byte("b")},
+		"card/c.qif":            {Data: []byte("c")},
+		"__MACOSX/d.qif":        {Data: []byte("d")},
+		"card/more.zip": {Data: zipOf(t, map[string]string{
+			"e.csv":     "e",
+			"sub/f.qif": "f",
+		})},
+	}
+
+	inputs, err := walkInputs(fsys, "dl", nil, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, in := range inputs {
+		got[filepath.ToSlash(in.path)] = in.source
+	}
+	want := map[string]string{
+		"dl/a.qif":                   "checking",
+		"dl/notes.txt":               "default",
+		"dl/card/b.csv":              "card",
+		"dl/card/c.qif":              "checking",
+		"dl/card/more.zip/e.csv":     "card",
+		"dl/card/more.zip/sub/f.qif": "checking",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLoadSourceRulesBad(t *testing.T) {
+	for _, data := range []string{
+		`[{"pattern": "[", "source": "x"}]`,
+		`[{"pattern": "*.qif"}]`,
+		`[{"pattern": "*.qif", "source": "x", "extra": 1}]`,
+	} {
+		fsys := fstest.MapFS{sourcesFile: {Data: []byte(data)}}
+		if _, err := loadSourceRules(fsys, ".", sourcesFile); err == nil {
+			t.Errorf("%s: expected error", data)
+		}
+	}
+}
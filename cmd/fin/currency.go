@@ -0,0 +1,156 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evmar/fin/bank/dates"
+)
+
+// setSourceCurrency sets the currency of entries from a source whose
+// input files don't say.
+func setSourceCurrency(tx *sql.Tx, source, currency string) error {
+	_, err := tx.Exec(`insert into source (name, currency) values (?, ?)
+		on conflict (name) do update set currency = excluded.currency`,
+		source, strings.ToUpper(currency))
+	return err
+}
+
+// importRates loads exchange rates from a CSV file with the columns
+// date, currency, base, rate, where one unit of currency is worth rate
+// units of base.  For example:
+//
+//	2024-01-31,EUR,USD,1.0837
+//
+// A header row is skipped if present.
+func importRates(db *sql.DB, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 4
+	count := 0
+	for line := 1; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(row[0]), "date") {
+			continue
+		}
+		date, err := dates.Parse(row[0], dates.YMD)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[3]), 64)
+		if err != nil || rate <= 0 {
+			return fmt.Errorf("%s:%d: bad rate %q", path, line, row[3])
+		}
+		_, err = tx.Exec(`insert or replace into rate (date, currency, base, rate) values (?, ?, ?, ?)`,
+			date.Format("2006/01/02"),
+			strings.ToUpper(strings.TrimSpace(row[1])), strings.ToUpper(strings.TrimSpace(row[2])), rate)
+		if err != nil {
+			return err
+		}
+		count++
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d rates\n", path, count)
+	return nil
+}
+
+type ratePoint struct {
+	date string
+	rate float64
+}
+
+// rates holds exchange rates, keyed by [currency, base] and sorted by
+// date.
+type rates map[[2]string][]ratePoint
+
+func loadRates(db *sql.DB) (rates, error) {
+	rows, err := db.Query(`select date, currency, base, rate from rate order by date`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rs := rates{}
+	for rows.Next() {
+		var p ratePoint
+		var currency, base string
+		if err := rows.Scan(&p.date, &currency, &base, &p.rate); err != nil {
+			return nil, err
+		}
+		key := [2]string{currency, base}
+		rs[key] = append(rs[key], p)
+	}
+	return rs, rows.Err()
+}
+
+// lookup returns the rate to convert from currency to base as of date,
+// which is the latest rate on or before date.  There is no rate for a
+// date before the first one known.  Rates are used in either direction.
+func (rs rates) lookup(currency, base, date string) (float64, bool) {
+	find := func(points []ratePoint) (float64, bool) {
+		i := sort.Search(len(points), func(i int) bool { return points[i].date > date })
+		if i == 0 {
+			return 0, false
+		}
+		return points[i-1].rate, true
+	}
+	if rate, ok := find(rs[[2]string{currency, base}]); ok {
+		return rate, true
+	}
+	if rate, ok := find(rs[[2]string{base, currency}]); ok {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+// convert converts an amount in cents from currency to base as of
+// date.  An empty currency is taken to already be in base.
+func (rs rates) convert(amount int, currency, base, date string) (int, bool) {
+	if currency == "" || currency == base {
+		return amount, true
+	}
+	rate, ok := rs.lookup(currency, base, date)
+	if !ok {
+		return amount, false
+	}
+	return int(math.Round(float64(amount) * rate)), true
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestConvert(t *testing.T) {
+	rs := rates{
+		{"EUR", "USD"}: {{"2024/01/31", 1.08}, {"2024/02/29", 1.10}},
+	}
+	tests := []struct {
+		amount         int
+		currency, base string
+		date           string
+		want           int
+		ok             bool
+	}{
+		{1000, "EUR", "USD", "2024/01/31", 1080, true},
+		{1000, "EUR", "USD", "2024/02/15", 1080, true},
+		{1000, "EUR", "USD", "2024/03/01", 1100, true},
+		{1100, "USD", "EUR", "2024/03/01", 1000, true},
+		{1000, "", "USD", "2024/03/01", 1000, true},
+		{1000, "USD", "USD", "2024/03/01", 1000, true},
+		// No rate is known yet, so the amount stays unconverted.
+		{1000, "EUR", "USD", "2024/01/30", 1000, false},
+		{1000, "GBP", "USD", "2024/03/01", 1000, false},
+	}
+	for _, test := range tests {
+		got, ok := rs.convert(test.amount, test.currency, test.base, test.date)
+		if got != test.want || ok != test.ok {
+			t.Errorf("convert(%d, %s, %s, %s) = %d, %v; want %d, %v", test.amount,
+				test.currency, test.base, test.date, got, ok, test.want, test.ok)
+		}
+	}
+}
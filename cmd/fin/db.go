@@ -16,18 +16,41 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
 
+	"github.com/evmar/fin/bank/qif"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Entry struct {
-	ID     int
-	Source string
-	Date   string
-	Payee  string
-	Amount int
-	Tags   []string
+	ID       int
+	Source   string
+	Date     string
+	Payee    string
+	From     string
+	To       string
+	Amount   int
+	Currency string
+	Number   string
+	Memo     string
+	Category string
+	Address  string
+	Cleared  qif.ClearedType
+	Tags     []string
+	Splits   []*Split
+}
+
+// Split is one part of a split transaction.  Splits are tagged
+// separately from their entry.
+type Split struct {
+	ID       int
+	EntryID  int
+	Category string
+	Memo     string
+	Amount   int
+	Tags     []string
 }
 
 type Tag struct {
@@ -36,12 +59,92 @@ type Tag struct {
 }
 
 func openDB() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "fin.db")
+	return openDBAt("fin.db")
+}
+
+// openDBAt opens the database at path, creating or upgrading its
+// tables as needed.
+func openDBAt(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return nil, err
 	}
+	if err := initDB(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
 
-	_, err = db.Exec(`
+// openDBCopy opens an in-memory copy of the database at path, or an
+// empty database if there is none, so that a dry run can import into
+// it without writing to the disk.
+func openDBCopy(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// Each connection has its own in-memory database.
+	db.SetMaxOpenConns(1)
+	if _, err := os.Stat(path); err == nil {
+		err = copyDB(db, path)
+	} else if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err == nil {
+		err = initDB(db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// copyDB copies the tables and indexes of the database at path, opened
+// read-only, into db.
+func copyDB(db *sql.DB, path string) error {
+	if _, err := db.Exec(`attach database ? as disk`, "file:"+path+"?mode=ro"); err != nil {
+		return err
+	}
+	rows, err := db.Query(`select type, name, sql from disk.sqlite_master
+		where sql is not null and name not like 'sqlite_%'
+		order by type = 'index'`)
+	if err != nil {
+		return err
+	}
+	type object struct{ typ, name, sql string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.typ, &o.name, &o.sql); err != nil {
+			rows.Close()
+			return err
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if _, err := db.Exec(o.sql); err != nil {
+			return fmt.Errorf("copying %s: %w", o.name, err)
+		}
+		if o.typ == "table" {
+			if _, err := db.Exec(fmt.Sprintf(`insert into main."%s" select * from disk."%s"`, o.name, o.name)); err != nil {
+				return fmt.Errorf("copying %s: %w", o.name, err)
+			}
+		}
+	}
+	_, err = db.Exec(`detach database disk`)
+	return err
+}
+
+// initDB creates the tables of a new database, or adds those and the
+// columns that a database from an older version of fin lacks.
+func initDB(db *sql.DB) error {
+	_, err := db.Exec(`
 	create table if not exists entry (
 		id integer primary key,
 		source text,
@@ -51,7 +154,97 @@ func openDB() (*sql.DB, error) {
 	)
 	`)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err := addColumn(db, "entry", "number", "text not null default ''"); err != nil {
+		return err
+	}
+	if err := addColumn(db, "entry", "batch", "integer"); err != nil {
+		return err
+	}
+	for _, col := range []string{"memo", "category", "address"} {
+		if err := addColumn(db, "entry", col, "text not null default ''"); err != nil {
+			return err
+		}
+	}
+	if err := addColumn(db, "entry", "cleared", "integer not null default 0"); err != nil {
+		return err
+	}
+	if err := addColumn(db, "entry", "currency", "text not null default ''"); err != nil {
+		return err
+	}
+	// The sender and recipient, for inputs that name both parties.
+	for _, col := range []string{"sender", "recipient"} {
+		if err := addColumn(db, "entry", col, "text not null default ''"); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec(`
+	create table if not exists source (
+		name text primary key,
+		currency text not null default ''
+	)
+	`)
+	if err != nil {
+		return err
+	}
+	if err := addColumn(db, "source", "charset", "text not null default ''"); err != nil {
+		return err
+	}
+	if err := addColumn(db, "source", "dates", "text not null default ''"); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+	create table if not exists rate (
+		date text not null,
+		currency text not null,
+		base text not null,
+		rate real not null,
+		primary key (date, currency, base)
+	)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+	create table if not exists split (
+		id integer primary key,
+		entryid integer not null,
+		category text not null,
+		memo text not null,
+		amount integer not null
+	)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+	create table if not exists splittag (
+		splitid integer not null,
+		tag string not null,
+		primary key (splitid, tag)
+	)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+	create table if not exists batch (
+		id integer primary key,
+		path text not null,
+		hash text not null,
+		source text not null,
+		time text not null,
+		count integer not null
+	)
+	`)
+	if err != nil {
+		return err
 	}
 
 	_, err = db.Exec(`
@@ -62,24 +255,56 @@ func openDB() (*sql.DB, error) {
 	)
 	`)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return db, nil
+	return nil
+}
+
+// addColumn adds a column to a table created by an older version of fin,
+// if the column isn't already present.
+func addColumn(db *sql.DB, table, column, decl string) error {
+	rows, err := db.Query(fmt.Sprintf("pragma table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notnull, pk int
+		var name, ctype string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf("alter table %s add column %s %s", table, column, decl))
+	return err
 }
 
 func allEntries(db *sql.DB) ([]*Entry, error) {
 	var entries []*Entry
 	byId := map[int]*Entry{}
 
-	rows, err := db.Query(`select id, source, date, payee, amount from entry`)
+	// An entry's currency comes from the input file if it said, and
+	// otherwise from its source.
+	rows, err := db.Query(`select id, source, date, payee, amount,
+		coalesce(nullif(entry.currency, ''), source.currency, ''),
+		number, memo, category, address, cleared, sender, recipient
+		from entry left join source on source.name = entry.source`)
 	if err != nil {
 		return nil, fmt.Errorf("select entries: %e", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
 		e := &Entry{}
-		if err := rows.Scan(&e.ID, &e.Source, &e.Date, &e.Payee, &e.Amount); err != nil {
+		if err := rows.Scan(&e.ID, &e.Source, &e.Date, &e.Payee, &e.Amount, &e.Currency,
+			&e.Number, &e.Memo, &e.Category, &e.Address, &e.Cleared, &e.From, &e.To); err != nil {
 			return nil, fmt.Errorf("scan: %e", err)
 		}
 		byId[e.ID] = e
@@ -101,5 +326,36 @@ func allEntries(db *sql.DB) ([]*Entry, error) {
 		e.Tags = append(e.Tags, tag)
 	}
 
+	splits := map[int]*Split{}
+	rows, err = db.Query(`select id, entryid, category, memo, amount from split order by id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		sp := &Split{}
+		if err := rows.Scan(&sp.ID, &sp.EntryID, &sp.Category, &sp.Memo, &sp.Amount); err != nil {
+			return nil, fmt.Errorf("scan: %e", err)
+		}
+		splits[sp.ID] = sp
+		e := byId[sp.EntryID]
+		e.Splits = append(e.Splits, sp)
+	}
+
+	rows, err = db.Query(`select splitid, tag from splittag`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, fmt.Errorf("scan: %e", err)
+		}
+		sp := splits[id]
+		sp.Tags = append(sp.Tags, tag)
+	}
+
 	return entries, nil
 }
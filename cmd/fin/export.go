@@ -0,0 +1,333 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/evmar/fin/bank/journal"
+	"github.com/evmar/fin/bank/qif"
+)
+
+// exportConfig maps fin's sources and tags to the accounts of a
+// journal.  It's read from a JSON file like:
+//
+//	{
+//	  "sources": {"checking": "Assets:Bank:Checking", "amex": "Liabilities:Amex"},
+//	  "tags": [
+//	    {"tag": "restaurants", "account": "Expenses:Food:Restaurants"},
+//	    {"tag": "food", "account": "Expenses:Food"}
+//	  ]
+//	}
+type exportConfig struct {
+	// Sources maps source names to asset or liability accounts.
+	// Other sources are exported as "Assets:<source>".
+	Sources map[string]string `json:"sources"`
+
+	// Tags are tried in order, and the first whose tag an entry has
+	// gives the account for the other side of the entry.
+	Tags []tagRule `json:"tags"`
+
+	// Expenses and Income are the accounts for money out and money in
+	// that no rule matches.
+	Expenses string `json:"expenses"`
+	Income   string `json:"income"`
+
+	// Currency is the currency of entries whose currency isn't known.
+	Currency string `json:"currency"`
+}
+
+type tagRule struct {
+	Tag     string `json:"tag"`
+	Account string `json:"account"`
+}
+
+func loadExportConfig(path string) (*exportConfig, error) {
+	config := &exportConfig{}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(config); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if config.Expenses == "" {
+		config.Expenses = "Expenses:Uncategorized"
+	}
+	if config.Income == "" {
+		config.Income = "Income:Uncategorized"
+	}
+	if config.Currency == "" {
+		config.Currency = "USD"
+	}
+	return config, nil
+}
+
+// accountName turns a source name into an account name, e.g.
+// "my checking" into "My-Checking".  A "/" as in "bank/Savings" starts
+// a subaccount.
+func accountName(source string) string {
+	var parts []string
+	for _, part := range strings.Split(source, "/") {
+		words := strings.FieldsFunc(part, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for i, w := range words {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+		if len(words) > 0 {
+			parts = append(parts, strings.Join(words, "-"))
+		}
+	}
+	if len(parts) == 0 {
+		return "Unknown"
+	}
+	return strings.Join(parts, ":")
+}
+
+func (c *exportConfig) sourceAccount(source string) string {
+	if account, ok := c.Sources[source]; ok {
+		return account
+	}
+	return "Assets:" + accountName(source)
+}
+
+// tagAccount returns the account of the first rule matching tags, or
+// "" if none does.
+func (c *exportConfig) tagAccount(tags []string) string {
+	for _, rule := range c.Tags {
+		for _, tag := range tags {
+			if tag == rule.Tag {
+				return rule.Account
+			}
+		}
+	}
+	return ""
+}
+
+// otherAccount returns the account for the other side of amount with
+// the given tags.
+func (c *exportConfig) otherAccount(amount int, tags ...[]string) string {
+	for _, tags := range tags {
+		if account := c.tagAccount(tags); account != "" {
+			return account
+		}
+	}
+	if amount < 0 {
+		return c.Expenses
+	}
+	return c.Income
+}
+
+// transaction converts an entry into a balanced journal transaction.
+func (c *exportConfig) transaction(e *Entry) (*journal.Transaction, error) {
+	date, err := time.Parse("2006/01/02", e.Date)
+	if err != nil {
+		return nil, fmt.Errorf("entry %d: %w", e.ID, err)
+	}
+	currency := e.Currency
+	if currency == "" {
+		currency = c.Currency
+	}
+	t := &journal.Transaction{
+		Date:    date,
+		Cleared: e.Cleared != qif.NotCleared,
+		Number:  e.Number,
+		Payee:   e.Payee,
+		Memo:    e.Memo,
+	}
+	t.Postings = append(t.Postings, journal.Posting{Account: c.sourceAccount(e.Source), Amount: e.Amount, Currency: currency})
+	rest := e.Amount
+	for _, sp := range e.Splits {
+		t.Postings = append(t.Postings, journal.Posting{
+			Account:  c.otherAccount(sp.Amount, sp.Tags, e.Tags),
+			Amount:   -sp.Amount,
+			Currency: currency,
+		})
+		rest -= sp.Amount
+	}
+	if rest != 0 || len(e.Splits) == 0 {
+		t.Postings = append(t.Postings, journal.Posting{
+			Account:  c.otherAccount(rest, e.Tags),
+			Amount:   -rest,
+			Currency: currency,
+		})
+	}
+	return t, nil
+}
+
+// exportFilter selects the entries to export.
+type exportFilter struct {
+	// source, if set, is the only source exported.
+	source string
+	// from and to, if set, bound the dates exported, inclusive, in the
+	// database's "2006/01/02" form.
+	from, to string
+}
+
+// exportEntries returns the entries matching filter, ordered by date.
+func exportEntries(db *sql.DB, filter *exportFilter) ([]*Entry, error) {
+	all, err := allEntries(db)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, e := range all {
+		if (filter.source != "" && e.Source != filter.source) ||
+			(filter.from != "" && e.Date < filter.from) ||
+			(filter.to != "" && e.Date > filter.to) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date < entries[j].Date
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+// exportJournal writes entries to w as a journal.
+func exportJournal(w io.Writer, entries []*Entry, format journal.Format, config *exportConfig) error {
+	var ts []*journal.Transaction
+	accounts := map[string]bool{}
+	for _, e := range entries {
+		t, err := config.transaction(e)
+		if err != nil {
+			return err
+		}
+		for _, p := range t.Postings {
+			accounts[p.Account] = true
+		}
+		ts = append(ts, t)
+	}
+	if len(ts) == 0 {
+		return nil
+	}
+
+	jw := journal.NewWriter(w, format)
+	var names []string
+	for account := range accounts {
+		names = append(names, account)
+	}
+	sort.Strings(names)
+	for _, account := range names {
+		if err := jw.Open(ts[0].Date, account); err != nil {
+			return err
+		}
+	}
+	for _, t := range ts {
+		if err := jw.WriteTransaction(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// qifCategory returns the QIF category for an entry or split.  A tag
+// rule's account gives the category as in journal.Category; otherwise
+// the category from the input is kept, or else the tags are joined
+// from the most to the least used, which is how fin orders the tag
+// hierarchy, e.g. "food:restaurants".
+func (c *exportConfig) qifCategory(tags []string, category string, counts map[string]int) string {
+	if account := c.tagAccount(tags); account != "" {
+		return journal.Category(account)
+	}
+	if category != "" || len(tags) == 0 {
+		return category
+	}
+	tags = append([]string(nil), tags...)
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	return strings.Join(tags, ":")
+}
+
+// exportQIF writes entries to w as QIF, with an account per source.
+func exportQIF(w io.Writer, entries []*Entry, config *exportConfig) error {
+	counts := map[string]int{}
+	bySource := map[string][]*Entry{}
+	var sources []string
+	for _, e := range entries {
+		for _, tag := range e.Tags {
+			counts[tag]++
+		}
+		for _, sp := range e.Splits {
+			for _, tag := range sp.Tags {
+				counts[tag]++
+			}
+		}
+		if _, ok := bySource[e.Source]; !ok {
+			sources = append(sources, e.Source)
+		}
+		bySource[e.Source] = append(bySource[e.Source], e)
+	}
+	sort.Strings(sources)
+
+	qw := qif.NewWriter(w)
+	for _, source := range sources {
+		if err := qw.WriteAccount(&qif.Account{Name: source, Type: "Bank"}); err != nil {
+			return err
+		}
+		if err := qw.WriteHeader("Bank"); err != nil {
+			return err
+		}
+		for _, e := range bySource[source] {
+			date, err := time.Parse("2006/01/02", e.Date)
+			if err != nil {
+				return fmt.Errorf("entry %d: %w", e.ID, err)
+			}
+			qe := &qif.Entry{
+				Number:   e.Number,
+				Date:     date,
+				Amount:   e.Amount,
+				Payee:    e.Payee,
+				Address:  e.Address,
+				Cleared:  e.Cleared,
+				Memo:     e.Memo,
+				Category: config.qifCategory(e.Tags, e.Category, counts),
+			}
+			for _, sp := range e.Splits {
+				qe.Splits = append(qe.Splits, qif.Split{
+					Category: config.qifCategory(sp.Tags, sp.Category, counts),
+					Memo:     sp.Memo,
+					Amount:   sp.Amount,
+				})
+			}
+			if err := qw.WriteEntry(qe); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
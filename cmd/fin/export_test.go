@@ -0,0 +1,110 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evmar/fin/bank/journal"
+	"github.com/evmar/fin/bank/qif"
+)
+
+func TestAccountName(t *testing.T) {
+	tests := []struct {
+		source, want string
+	}{
+		{"checking", "Checking"},
+		{"my checking", "My-Checking"},
+		{"bank/Savings", "Bank:Savings"},
+		{"--", "Unknown"},
+	}
+	for _, test := range tests {
+		if got := accountName(test.source); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.source, got, test.want)
+		}
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	config := &exportConfig{
+		Sources: map[string]string{"amex": "Liabilities:Amex"},
+		Tags: []tagRule{
+			{Tag: "restaurants", Account: "Expenses:Food:Restaurants"},
+			{Tag: "food", Account: "Expenses:Food"},
+		},
+		Expenses: "Expenses:Uncategorized",
+		Income:   "Income:Uncategorized",
+		Currency: "USD",
+	}
+	tests := []struct {
+		name  string
+		entry *Entry
+		want  []journal.Posting
+	}{
+		{
+			name:  "untagged expense",
+			entry: &Entry{Source: "checking", Date: "2026/03/02", Amount: -1250},
+			want: []journal.Posting{
+				{Account: "Assets:Checking", Amount: -1250, Currency: "USD"},
+				{Account: "Expenses:Uncategorized", Amount: 1250, Currency: "USD"},
+			},
+		},
+		{
+			name:  "tagged income in another currency",
+			entry: &Entry{Source: "amex", Date: "2026/03/02", Amount: 500, Currency: "EUR", Tags: []string{"food"}},
+			want: []journal.Posting{
+				{Account: "Liabilities:Amex", Amount: 500, Currency: "EUR"},
+				{Account: "Expenses:Food", Amount: -500, Currency: "EUR"},
+			},
+		},
+		{
+			name: "splits with remainder",
+			entry: &Entry{
+				Source: "checking", Date: "2026/03/02", Amount: -3000, Tags: []string{"food"},
+				Splits: []*Split{
+					{Amount: -2000, Tags: []string{"restaurants"}},
+					{Amount: -500},
+				},
+			},
+			want: []journal.Posting{
+				{Account: "Assets:Checking", Amount: -3000, Currency: "USD"},
+				{Account: "Expenses:Food:Restaurants", Amount: 2000, Currency: "USD"},
+				{Account: "Expenses:Food", Amount: 500, Currency: "USD"},
+				{Account: "Expenses:Food", Amount: 500, Currency: "USD"},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := config.transaction(test.entry)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got.Postings, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got.Postings, test.want)
+		}
+	}
+
+	cleared, err := config.transaction(&Entry{Date: "2026/03/02", Cleared: qif.Cleared})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cleared.Cleared {
+		t.Errorf("cleared entry exported as uncleared")
+	}
+	if _, err := config.transaction(&Entry{Date: "03/02/2026"}); err == nil {
+		t.Errorf("bad date: expected error")
+	}
+}
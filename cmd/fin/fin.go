@@ -15,9 +15,17 @@
 package main
 
 import (
+	"database/sql"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/dates"
+	"github.com/evmar/fin/bank/journal"
 )
 
 func run() error {
@@ -42,27 +50,163 @@ func run() error {
 			return err
 		}
 
+		fs := flag.NewFlagSet("web", flag.ExitOnError)
+		currency := fs.String("currency", "", "convert amounts to this reporting currency")
+		fs.Parse(args)
+
 		w := web{
-			db: db,
+			db:       db,
+			currency: strings.ToUpper(*currency),
 		}
 		w.start(":8888")
 	case "import":
-		if len(args) != 2 {
-			fmt.Println("usage: import path name")
+		fs := flag.NewFlagSet("import", flag.ExitOnError)
+		undo := fs.Int("undo", 0, "remove the entries added by the given import batch")
+		opts := importFlags(fs)
+		fs.BoolVar(&opts.dryRun, "dry-run", false, "show what would be imported without writing to the database")
+		fs.Parse(args)
+		args = fs.Args()
+		if *undo != 0 {
+			if len(args) != 0 {
+				fmt.Println("usage: import --undo batch")
+				return nil
+			}
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			return undoBatch(db, *undo)
+		}
+		if len(args) != 1 && len(args) != 2 {
+			fmt.Println("usage: import path|dir|zip|- [name]")
+			return nil
+		}
+		var db *sql.DB
+		var err error
+		if opts.dryRun {
+			// A dry run imports into a copy, leaving fin.db untouched.
+			db, err = openDBCopy("fin.db")
+		} else {
+			db, err = openDB()
+		}
+		if err != nil {
+			return err
+		}
+		path, name := args[0], ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		return importPath(db, path, name, opts)
+	case "watch":
+		fs := flag.NewFlagSet("watch", flag.ExitOnError)
+		interval := fs.Duration("interval", 10*time.Second, "how often to look for new files")
+		archive := fs.String("archive", "archive", "directory, relative to the watched one, to move imported files to")
+		opts := importFlags(fs)
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) != 1 && len(args) != 2 {
+			fmt.Println("usage: watch dir [name]")
 			return nil
 		}
 		db, err := openDB()
 		if err != nil {
 			return err
 		}
-		path, name := args[0], args[1]
-		return importFile(db, path, name)
+		name := ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		newWatcher(db, args[0], *archive, name, opts).run(*interval)
+	case "rates":
+		if len(args) != 1 {
+			fmt.Println("usage: rates path.csv")
+			return nil
+		}
+		db, err := openDB()
+		if err != nil {
+			return err
+		}
+		return importRates(db, args[0])
+	case "export":
+		fs := flag.NewFlagSet("export", flag.ExitOnError)
+		format := fs.String("format", "beancount", "output format: beancount, ledger, or qif")
+		configPath := fs.String("config", "", "path to a JSON file mapping sources and tags to accounts")
+		var filter exportFilter
+		fs.StringVar(&filter.source, "source", "", "only export entries from this source")
+		dateFlag := func(name, usage string, date *string) {
+			fs.Func(name, usage, func(s string) error {
+				t, err := dates.Parse(s, dates.YMD)
+				*date = t.Format("2006/01/02")
+				return err
+			})
+		}
+		dateFlag("from", "only export entries on or after this date, e.g. 2024-01-01", &filter.from)
+		dateFlag("to", "only export entries on or before this date", &filter.to)
+		fs.Parse(args)
+		if len(fs.Args()) != 0 {
+			fmt.Println("usage: export [-format beancount|ledger|qif] [-config path] [-source name] [-from date] [-to date]")
+			return nil
+		}
+		config, err := loadExportConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		db, err := openDB()
+		if err != nil {
+			return err
+		}
+		entries, err := exportEntries(db, &filter)
+		if err != nil {
+			return err
+		}
+		if *format == "qif" {
+			return exportQIF(os.Stdout, entries, config)
+		}
+		jformat, err := journal.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+		return exportJournal(os.Stdout, entries, jformat, config)
+	case "formats":
+		listFormats(os.Stdout)
+	case "imports":
+		db, err := openDB()
+		if err != nil {
+			return err
+		}
+		return listBatches(db, os.Stdout)
 	default:
 		return fmt.Errorf("unknown mode %q", mode)
 	}
 	return nil
 }
 
+// importFlags defines the flags that control importing, shared by the
+// import and watch modes.
+func importFlags(fs *flag.FlagSet) *importOptions {
+	opts := &importOptions{}
+	fs.BoolVar(&opts.categoryTags, "category-tags", false, "tag entries with the categories from the input")
+	fs.Func("dates", "order of date fields in the input: mdy, dmy, ymd, or auto", func(s string) error {
+		order, err := dates.ParseOrder(s)
+		opts.read.DateOrder = &order
+		return err
+	})
+	fs.StringVar(&opts.format, "format", "", "format of the input, instead of detecting it; see \"fin formats\"")
+	fs.StringVar(&opts.read.CSVProfiles, "csv-profiles", "", "path to a JSON file of CSV profiles")
+	fs.StringVar(&opts.read.CSVProfile, "csv-profile", "", "name of the profile to read CSV files with")
+	fs.StringVar(&opts.read.Account, "account", "", "account to import from a Beancount or Ledger journal")
+	fs.StringVar(&opts.currency, "currency", "", "currency of the account, for inputs that don't say")
+	fs.Func("charset", "character encoding of the input, e.g. utf-8 or windows-1252, instead of detecting it", func(s string) error {
+		cs, ok := charset.Lookup(s)
+		if !ok {
+			return fmt.Errorf("unknown charset %q", s)
+		}
+		opts.charset = cs
+		return nil
+	})
+	return opts
+}
+
 func main() {
 	if err := run(); err != nil {
 		log.Fatal(err)
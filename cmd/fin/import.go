@@ -15,68 +15,385 @@
 package main
 
 import (
+	"bytes"
 	"database/sql"
+	"fmt"
 	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"strings"
 
-	qifcsv "github.com/evmar/fin/bank/csv"
+	"github.com/evmar/fin/bank"
+	"github.com/evmar/fin/bank/charset"
+	"github.com/evmar/fin/bank/dates"
 	"github.com/evmar/fin/bank/qif"
+
+	// The formats that can be imported.
+	_ "github.com/evmar/fin/bank/all"
 )
 
-type QIFRead interface {
-	ReadEntry() (*qif.Entry, error)
+// readInput reads the file to import, or stdin if path is "-".
+// Statements are small, so they are read whole to be both hashed
+// and parsed.
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
 }
 
-func parse(path string) ([]*qif.Entry, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+// inputFormat returns the format to read path as: the one named by
+// the -format flag, or else the one its content and name imply.
+func inputFormat(path string, data []byte, opts *importOptions) (*bank.Format, error) {
+	if opts.format != "" {
+		format := bank.Lookup(opts.format)
+		if format == nil {
+			return nil, fmt.Errorf("unknown format %q; see \"fin formats\"", opts.format)
+		}
+		return format, nil
+	}
+	if format := bank.Identify(path, data); format != nil {
+		return format, nil
 	}
-	defer f.Close()
+	return nil, fmt.Errorf("%s: unknown format; choose one with -format (see \"fin formats\")", path)
+}
 
-	var entries []*qif.Entry
-	var qr QIFRead
+// listFormats prints the formats that can be imported.
+func listFormats(w io.Writer) {
+	for _, f := range bank.Formats() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, strings.Join(f.Extensions, " "), f.Description)
+	}
+}
 
-	ext := filepath.Ext(path)
-	switch ext {
-	case ".qif":
-		r := qif.NewReader(f)
-		ttype, err := r.ReadHeader()
-		if err != nil {
-			return nil, err
-		}
-		log.Printf("%s: %q", path, ttype)
-		qr = r
-	case ".csv", ".CSV":
-		r, err := qifcsv.NewCSVReader(f)
-		if err != nil {
-			return nil, err
-		}
-		log.Printf("%s: csv", path)
-		qr = r
-	default:
-		log.Printf("%s: unknown format %q", path, ext)
+func parse(path string, data []byte, format *bank.Format, opts *importOptions) ([]*qif.Entry, error) {
+	var in io.Reader = bytes.NewReader(data)
+	if opts.charset != "" {
+		log.Printf("%s: reading as %s", path, opts.charset)
+		in = charset.NewReader(in, opts.charset)
 	}
 
+	log.Printf("%s: %s", path, format.Name)
+	r, err := format.Open(in, &opts.read)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var entries []*qif.Entry
 	for {
-		entry, err := qr.ReadEntry()
+		entry, err := r.ReadEntry()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return nil, err
+			return nil, fmt.Errorf("%s: %w", path, err)
 		}
-
 		entries = append(entries, entry)
 	}
 
+	if s, ok := r.(bank.Summarizer); ok {
+		for _, line := range s.Summary() {
+			log.Printf("%s: %s", path, line)
+		}
+	}
+
 	return entries, nil
 }
 
-func importFile(db *sql.DB, path, name string) error {
-	entries, err := parse(path)
+// importStatus is the outcome of importing a single entry.
+type importStatus int
+
+const (
+	// statusNew means no matching entry exists in the database.
+	statusNew importStatus = iota
+	// statusSkipped means the entry is already present in the database.
+	statusSkipped
+	// statusAmbiguous means the database has some entries that match,
+	// but fewer than the input contains.  These are imported, because
+	// repeated identical charges on the same day do happen, but are
+	// worth a look.
+	statusAmbiguous
+)
+
+// entryKey is the identity of an entry that has no Number.
+type entryKey struct {
+	date   string
+	amount int
+	payee  string
+}
+
+func keyOf(e *qif.Entry) entryKey {
+	return entryKey{e.Date.Format("2006/01/02"), e.Amount, e.Payee}
+}
+
+// dedupe compares entries against the existing entries from the same
+// source and returns the importStatus of each.
+//
+// An entry with a Number matches an existing entry with the same
+// Number.  Otherwise entries match on date, amount, and payee; if the
+// input contains the same key n times and the database m times, the
+// first m are skipped.  An entry with a Number only matches existing
+// entries without one on the key, since identical charges on the same
+// day have different Numbers.
+func dedupe(tx *sql.Tx, source string, entries []*qif.Entry) ([]importStatus, error) {
+	statuses := make([]importStatus, len(entries))
+	if len(entries) == 0 {
+		return statuses, nil
+	}
+
+	first, last := entries[0].Date, entries[0].Date
+	for _, e := range entries {
+		if e.Date.Before(first) {
+			first = e.Date
+		}
+		if e.Date.After(last) {
+			last = e.Date
+		}
+	}
+
+	rows, err := tx.Query(`select date, payee, amount, number from entry
+		where source = ? and date between ? and ?`,
+		source, first.Format("2006/01/02"), last.Format("2006/01/02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	numbers := map[string]bool{}
+	// counts holds the existing entries by key, and unnumbered those
+	// of them without a Number.
+	counts := map[entryKey]int{}
+	unnumbered := map[entryKey]int{}
+	for rows.Next() {
+		var k entryKey
+		var number string
+		if err := rows.Scan(&k.date, &k.payee, &k.amount, &number); err != nil {
+			return nil, err
+		}
+		if number != "" {
+			numbers[number] = true
+		} else {
+			unnumbered[k]++
+		}
+		counts[k]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	seen := map[entryKey]int{}
+	for i, e := range entries {
+		k := keyOf(e)
+		existing := counts[k]
+		if e.Number != "" {
+			if numbers[e.Number] {
+				statuses[i] = statusSkipped
+				continue
+			}
+			existing = unnumbered[k]
+		}
+		seen[k]++
+		switch {
+		case existing == 0:
+			statuses[i] = statusNew
+		case seen[k] <= existing:
+			statuses[i] = statusSkipped
+		default:
+			statuses[i] = statusAmbiguous
+		}
+	}
+	return statuses, nil
+}
+
+// splitSources divides entries by the source they are imported into.
+// Normally that is name, but a file with several accounts, such as a
+// full Quicken export, imports each account into its own source named
+// like "name/Checking".  Sources are returned in order of appearance.
+func splitSources(name string, entries []*qif.Entry) ([]string, map[string][]*qif.Entry) {
+	accounts := map[string]bool{}
+	for _, e := range entries {
+		accounts[e.Account] = true
+	}
+
+	var sources []string
+	bySource := map[string][]*qif.Entry{}
+	for _, e := range entries {
+		source := name
+		if len(accounts) > 1 && e.Account != "" {
+			source = name + "/" + e.Account
+		}
+		if _, ok := bySource[source]; !ok {
+			sources = append(sources, source)
+		}
+		bySource[source] = append(bySource[source], e)
+	}
+	return sources, bySource
+}
+
+// importOptions holds the options to "fin import".
+type importOptions struct {
+	// categoryTags turns the categories in the input into tags.
+	categoryTags bool
+
+	// format, if set, names the format to read the input as, instead
+	// of detecting it.
+	format string
+
+	// read holds the options for the format's reader.  Its DateOrder,
+	// if set, is remembered for the source like charset.
+	read bank.Options
+
+	// dryRun imports as usual but rolls back the transaction, and
+	// prints what the import would have done.
+	dryRun bool
+
+	// currency, if set, is recorded as the currency of the source, for
+	// formats that don't say.
+	currency string
+
+	// charset, if set, overrides the detected character encoding of
+	// the input.  It's remembered for the source, so later imports of
+	// the same source use it too.
+	charset charset.Charset
+}
+
+// sourceCharset returns the charset recorded for a source, if any.
+func sourceCharset(tx *sql.Tx, source string) (charset.Charset, error) {
+	var cs string
+	err := tx.QueryRow(`select charset from source where name = ?`, source).Scan(&cs)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return charset.Charset(cs), err
+}
+
+// setSourceCharset records the charset of the files of a source.
+func setSourceCharset(tx *sql.Tx, source string, cs charset.Charset) error {
+	_, err := tx.Exec(`insert into source (name, charset) values (?, ?)
+		on conflict (name) do update set charset = excluded.charset`,
+		source, string(cs))
+	return err
+}
+
+// sourceDateOrder returns the date order recorded for a source, or nil
+// if there is none.
+func sourceDateOrder(tx *sql.Tx, source string) (*dates.Order, error) {
+	var name string
+	err := tx.QueryRow(`select dates from source where name = ?`, source).Scan(&name)
+	if err == sql.ErrNoRows || (err == nil && name == "") {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	order, err := dates.ParseOrder(name)
+	if err != nil {
+		return nil, fmt.Errorf("source %s: %w", source, err)
+	}
+	return &order, nil
+}
+
+// setSourceDateOrder records the date order of the files of a source.
+func setSourceDateOrder(tx *sql.Tx, source string, order dates.Order) error {
+	_, err := tx.Exec(`insert into source (name, dates) values (?, ?)
+		on conflict (name) do update set dates = excluded.dates`,
+		source, order.String())
+	return err
+}
+
+// categoryTags converts a category like "Food:Restaurants" into the
+// tags "food" and "restaurants".  fin infers the tag hierarchy from
+// which tags occur together, so the parent tag is kept too.  Any class
+// ("Food/Business") is dropped, and transfers between accounts
+// ("[Savings]") have no tags.
+func categoryTags(category string) []string {
+	category, _, _ = strings.Cut(category, "/")
+	category = strings.TrimSpace(category)
+	if category == "" || strings.HasPrefix(category, "[") {
+		return nil
+	}
+	var tags []string
+	for _, part := range strings.Split(category, ":") {
+		tag := strings.Join(strings.Fields(strings.ToLower(part)), "-")
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func insertEntry(tx *sql.Tx, name string, batch int, entry *qif.Entry, opts *importOptions) error {
+	res, err := tx.Exec(`insert into entry
+		(source, date, payee, amount, currency, number, memo, category, address, cleared, sender, recipient, batch)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		name, entry.Date.Format("2006/01/02"), entry.Payee, entry.Amount, entry.Currency,
+		entry.Number, entry.Memo, entry.Category, entry.Address, entry.Cleared, entry.From, entry.To, batch,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if opts.categoryTags {
+		for _, tag := range categoryTags(entry.Category) {
+			if _, err := tx.Exec(`insert or ignore into tag (entryid, tag) values (?, ?)`, id, tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, split := range entry.Splits {
+		res, err := tx.Exec("insert into split (entryid, category, memo, amount) values (?, ?, ?, ?)",
+			id, split.Category, split.Memo, split.Amount,
+		)
+		if err != nil {
+			return err
+		}
+		if !opts.categoryTags {
+			continue
+		}
+		splitID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, tag := range categoryTags(split.Category) {
+			if _, err := tx.Exec(`insert or ignore into splittag (splitid, tag) values (?, ?)`, splitID, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importInput is a statement file to import.
+type importInput struct {
+	// path names the file in messages, e.g. "2024.zip/jan.qif".
+	path string
+	data []byte
+	// source is the source to import into, or empty if unknown.
+	source string
+}
+
+// importResult is the outcome of importing one source from a file.
+type importResult struct {
+	path   string
+	format string
+	source string
+	batch  int
+	counts [3]int
+	// entries are the entries read for the source, and statuses their
+	// importStatus.
+	entries  []*qif.Entry
+	statuses []importStatus
+	// skipped is why the file wasn't imported, if it wasn't.
+	skipped string
+}
+
+// importPath imports the statement file, directory, or zip archive at
+// path in a single transaction.  A directory or archive in which every
+// file is skipped is an error, so that e.g. the watcher keeps it to
+// retry once the sources file is fixed.
+func importPath(db *sql.DB, path, name string, opts *importOptions) error {
+	inputs, bulk, err := collectInputs(path, name)
 	if err != nil {
 		return err
 	}
@@ -85,14 +402,155 @@ func importFile(db *sql.DB, path, name string) error {
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	for _, entry := range entries {
-		_, err := tx.Exec("insert into entry (source, date, payee, amount) values (?, ?, ?, ?)",
-			name, entry.Date.Format("2006/01/02"), entry.Payee, entry.Amount,
-		)
+	var results []*importResult
+	for _, in := range inputs {
+		format, err := inputFormat(in.path, in.data, opts)
+		if err != nil {
+			if !bulk {
+				return err
+			}
+			results = append(results, &importResult{path: in.path, skipped: "unknown format"})
+			continue
+		}
+		if in.source == "" {
+			results = append(results, &importResult{path: in.path, format: format.Name, skipped: "no source"})
+			continue
+		}
+		rs, err := importData(tx, in, format, opts)
 		if err != nil {
 			return err
 		}
+		results = append(results, rs...)
+	}
+	if opts.dryRun {
+		// Leave the transaction to be rolled back.
+		return printPreview(os.Stdout, results)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if bulk {
+		if err := printImportSummary(os.Stdout, results); err != nil {
+			return err
+		}
+		for _, r := range results {
+			if r.skipped == "" {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: no files imported", path)
+	}
+	for _, r := range results {
+		fmt.Printf("%s: %s: %d new, %d skipped, %d ambiguous\n",
+			r.path, r.source, r.counts[statusNew], r.counts[statusSkipped], r.counts[statusAmbiguous])
+	}
+	fmt.Printf("%s: batch %d\n", path, results[0].batch)
+	return nil
+}
+
+// importData imports one file as a batch, returning a result for each
+// source the file's entries went into.
+func importData(tx *sql.Tx, in *importInput, format *bank.Format, opts *importOptions) ([]*importResult, error) {
+	path, name := in.path, in.source
+	if format.CategoryTags {
+		o := *opts
+		o.categoryTags = true
+		opts = &o
+	}
+	// A charset given on the command line is remembered for the
+	// source; otherwise any remembered one is used.
+	setCharset := opts.charset != ""
+	if !setCharset {
+		cs, err := sourceCharset(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		o := *opts
+		o.charset = cs
+		opts = &o
+	}
+	// Likewise for the date order.
+	setDateOrder := opts.read.DateOrder != nil
+	if !setDateOrder {
+		order, err := sourceDateOrder(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		o := *opts
+		o.read.DateOrder = order
+		opts = &o
+	}
+	entries, err := parse(path, in.data, format, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hashData(in.data)
+	if prev, err := findBatchByHash(tx, hash); err != nil {
+		return nil, err
+	} else if prev != 0 {
+		log.Printf("%s: same contents were already imported as batch %d", path, prev)
+	}
+
+	batch, err := createBatch(tx, path, hash, name)
+	if err != nil {
+		return nil, err
+	}
+	if setCharset {
+		if err := setSourceCharset(tx, name, opts.charset); err != nil {
+			return nil, err
+		}
+	}
+	if setDateOrder {
+		if err := setSourceDateOrder(tx, name, *opts.read.DateOrder); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []*importResult
+	sources, bySource := splitSources(name, entries)
+	var total [3]int
+	for _, source := range sources {
+		if opts.currency != "" {
+			if err := setSourceCurrency(tx, source, opts.currency); err != nil {
+				return nil, err
+			}
+		}
+		entries := bySource[source]
+		statuses, err := dedupe(tx, source, entries)
+		if err != nil {
+			return nil, err
+		}
+
+		r := &importResult{path: path, format: format.Name, source: source, batch: batch,
+			entries: entries, statuses: statuses}
+		for i, entry := range entries {
+			status := statuses[i]
+			r.counts[status]++
+			switch status {
+			case statusSkipped:
+				continue
+			case statusAmbiguous:
+				log.Printf("%s: possible duplicate: %s %q %d",
+					path, entry.Date.Format("2006/01/02"), entry.Payee, entry.Amount)
+			}
+			if err := insertEntry(tx, source, batch, entry, opts); err != nil {
+				return nil, err
+			}
+		}
+		for i := range r.counts {
+			total[i] += r.counts[i]
+		}
+		results = append(results, r)
+	}
+	if len(results) == 0 {
+		results = append(results, &importResult{path: path, format: format.Name, source: name, batch: batch})
+	}
+	if err := finishBatch(tx, batch, total[statusNew]+total[statusAmbiguous]); err != nil {
+		return nil, err
 	}
-	return tx.Commit()
+	return results, nil
 }
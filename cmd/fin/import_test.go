@@ -0,0 +1,245 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/evmar/fin/bank"
+	"github.com/evmar/fin/bank/dates"
+	"github.com/evmar/fin/bank/qif"
+)
+
+func date(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+// testDB opens an empty database in memory.
+func testDB(t *testing.T) *sql.DB {
+	db, err := openDBAt("file:" + t.Name() + "?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDedupe(t *testing.T) {
+	coffee := func(number string) *qif.Entry {
+		return &qif.Entry{Date: date(2026, 3, 2), Amount: -450, Payee: "COFFEE", Number: number}
+	}
+	tests := []struct {
+		name     string
+		existing []*qif.Entry
+		entries  []*qif.Entry
+		want     []importStatus
+	}{
+		{
+			name:    "empty database",
+			entries: []*qif.Entry{coffee(""), coffee("")},
+			want:    []importStatus{statusNew, statusNew},
+		},
+		{
+			name:     "same key",
+			existing: []*qif.Entry{coffee("")},
+			entries:  []*qif.Entry{coffee("")},
+			want:     []importStatus{statusSkipped},
+		},
+		{
+			name:     "more in input",
+			existing: []*qif.Entry{coffee("")},
+			entries:  []*qif.Entry{coffee(""), coffee("")},
+			want:     []importStatus{statusSkipped, statusAmbiguous},
+		},
+		{
+			name:     "other date",
+			existing: []*qif.Entry{{Date: date(2026, 3, 1), Amount: -450, Payee: "COFFEE"}},
+			entries:  []*qif.Entry{coffee("")},
+			want:     []importStatus{statusNew},
+		},
+		{
+			name:     "same number",
+			existing: []*qif.Entry{coffee("NF1")},
+			entries:  []*qif.Entry{{Date: date(2026, 3, 2), Amount: -450, Payee: "Coffee Shop", Number: "NF1"}},
+			want:     []importStatus{statusSkipped},
+		},
+		{
+			name:     "new number with same key",
+			existing: []*qif.Entry{coffee("NF1")},
+			entries:  []*qif.Entry{coffee("NF1"), coffee("NF2")},
+			want:     []importStatus{statusSkipped, statusNew},
+		},
+		{
+			name:     "number against unnumbered",
+			existing: []*qif.Entry{coffee("")},
+			entries:  []*qif.Entry{coffee("NF1"), coffee("NF2")},
+			want:     []importStatus{statusSkipped, statusAmbiguous},
+		},
+		{
+			name:     "unnumbered against number",
+			existing: []*qif.Entry{coffee("NF1")},
+			entries:  []*qif.Entry{coffee("")},
+			want:     []importStatus{statusSkipped},
+		},
+	}
+
+	db := testDB(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+			for _, e := range test.existing {
+				if err := insertEntry(tx, "card", 0, e, &importOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := dedupe(tx, "card", test.entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSplitSources(t *testing.T) {
+	a := &qif.Entry{Payee: "a", Account: "Checking"}
+	b := &qif.Entry{Payee: "b", Account: "Savings"}
+	c := &qif.Entry{Payee: "c", Account: "Checking"}
+
+	sources, bySource := splitSources("bank", []*qif.Entry{a, b, c})
+	if want := []string{"bank/Checking", "bank/Savings"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("sources: got %q, want %q", sources, want)
+	}
+	want := map[string][]*qif.Entry{
+		"bank/Checking": {a, c},
+		"bank/Savings":  {b},
+	}
+	if !reflect.DeepEqual(bySource, want) {
+		t.Errorf("got %v, want %v", bySource, want)
+	}
+
+	// A single account keeps the source name.
+	sources, _ = splitSources("bank", []*qif.Entry{a, c})
+	if want := []string{"bank"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("one account: got %q, want %q", sources, want)
+	}
+}
+
+func TestCategoryTags(t *testing.T) {
+	tests := []struct {
+		category string
+		want     []string
+	}{
+		{"", nil},
+		{"Groceries", []string{"groceries"}},
+		{"Food:Restaurants", []string{"food", "restaurants"}},
+		{"Food:Fast  Food/Business", []string{"food", "fast-food"}},
+		{"[Savings]", nil},
+		{"Auto::Fuel", []string{"auto", "fuel"}},
+	}
+	for _, test := range tests {
+		if got := categoryTags(test.category); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.category, got, test.want)
+		}
+	}
+}
+
+func TestRememberDateOrder(t *testing.T) {
+	db := testDB(t)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	importDate := func(data string, opts *importOptions) time.Time {
+		in := &importInput{path: "test.qif", data: []byte(data), source: "euro"}
+		results, err := importData(tx, in, bank.Lookup("qif"), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results[0].entries[0].Date
+	}
+
+	dmy := dates.DMY
+	opts := &importOptions{}
+	opts.read.DateOrder = &dmy
+	if got := importDate("!Type:Bank\nD02/01/2013\nPFOO\nT1.00\n^\n", opts); got != date(2013, 1, 2) {
+		t.Errorf("with -dates: got %v", got)
+	}
+	// A later import of the source uses the same order.
+	if got := importDate("!Type:Bank\nD03/01/2013\nPBAR\nT1.00\n^\n", &importOptions{}); got != date(2013, 1, 3) {
+		t.Errorf("remembered: got %v", got)
+	}
+}
+
+func TestFromTo(t *testing.T) {
+	db := testDB(t)
+	addBatch(t, db, "venmo",
+		&qif.Entry{Date: date(2024, 1, 5), Amount: -1250, Payee: "Bob", From: "Alice", To: "Bob"},
+		&qif.Entry{Date: date(2024, 1, 6), Amount: 500, Payee: "BANK"},
+	)
+	entries, err := allEntries(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][2]string
+	for _, e := range entries {
+		got = append(got, [2]string{e.From, e.To})
+	}
+	want := [][2]string{{"Alice", "Bob"}, {"", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJournalCategoryTags(t *testing.T) {
+	db := testDB(t)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	const journal = "2024-01-05 * \"Cafe\"\n  Expenses:Food:Restaurants  12.50 USD\n  Assets:Checking\n"
+	in := &importInput{path: "test.beancount", data: []byte(journal), source: "checking"}
+	opts := &importOptions{}
+	opts.read.Account = "Assets:Checking"
+	if _, err := importData(tx, in, bank.Lookup("journal"), opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := allEntries(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].Tags) == 0 {
+		t.Fatalf("expected a tagged entry, got %+v", entries)
+	}
+	if opts.categoryTags {
+		t.Errorf("importData changed the caller's options")
+	}
+}
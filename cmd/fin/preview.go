@@ -0,0 +1,112 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/evmar/fin/bank/money"
+)
+
+var statusNames = [...]string{
+	statusNew:       "new",
+	statusSkipped:   "duplicate",
+	statusAmbiguous: "possible duplicate",
+}
+
+// flows sums the money in and out in one currency.
+type flows struct {
+	in, out int
+}
+
+// printPreview prints what an import would do: for each file and
+// source, the dates covered and each entry with its status, then the
+// totals of the entries that would be added.  Entries are listed as
+// read, after a file with several accounts is split into sources, so
+// e.g. a sign inversion shows in the amounts.
+func printPreview(w io.Writer, results []*importResult) error {
+	var counts [3]int
+	for _, r := range results {
+		if r.skipped != "" {
+			fmt.Fprintf(w, "%s: skipped: %s\n\n", r.path, r.skipped)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s into %s", r.path, r.format, r.source)
+		if len(r.entries) == 0 {
+			fmt.Fprintf(w, ": no entries\n\n")
+			continue
+		}
+		first, last := r.entries[0].Date, r.entries[0].Date
+		for _, e := range r.entries {
+			if e.Date.Before(first) {
+				first = e.Date
+			}
+			if e.Date.After(last) {
+				last = e.Date
+			}
+		}
+		fmt.Fprintf(w, ", %s to %s\n", first.Format("2006/01/02"), last.Format("2006/01/02"))
+
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		sums := map[string]*flows{}
+		for i, e := range r.entries {
+			status := r.statuses[i]
+			fmt.Fprintf(tw, "  %s\t%s\t%10s %s\t%s\n", statusNames[status],
+				e.Date.Format("2006/01/02"), money.Format(e.Amount), e.Currency, e.Payee)
+			if status == statusSkipped {
+				continue
+			}
+			f := sums[e.Currency]
+			if f == nil {
+				f = &flows{}
+				sums[e.Currency] = f
+			}
+			if e.Amount < 0 {
+				f.out += e.Amount
+			} else {
+				f.in += e.Amount
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "  %d new, %d duplicate, %d possible duplicate\n",
+			r.counts[statusNew], r.counts[statusSkipped], r.counts[statusAmbiguous])
+		var currencies []string
+		for c := range sums {
+			currencies = append(currencies, c)
+		}
+		sort.Strings(currencies)
+		for _, c := range currencies {
+			f := sums[c]
+			if c != "" {
+				c = " " + c
+			}
+			fmt.Fprintf(w, "  to add: in %s, out %s, net %s%s\n",
+				money.Format(f.in), money.Format(f.out), money.Format(f.in+f.out), c)
+		}
+		fmt.Fprintln(w)
+		for i := range counts {
+			counts[i] += r.counts[i]
+		}
+	}
+	fmt.Fprintf(w, "dry run: %d new, %d duplicate, %d possible duplicate; nothing was written\n",
+		counts[statusNew], counts[statusSkipped], counts[statusAmbiguous])
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+func TestPrintPreview(t *testing.T) {
+	results := []*importResult{
+		{
+			path: "march.qif", format: "qif", source: "checking",
+			counts: [3]int{statusNew: 2, statusSkipped: 1},
+			entries: []*qif.Entry{
+				{Date: date(2026, 3, 2), Amount: -450, Payee: "COFFEE"},
+				{Date: date(2026, 3, 1), Amount: 250000, Payee: "PAYROLL"},
+				{Date: date(2026, 3, 5), Amount: -1250, Currency: "EUR", Payee: "CAFE"},
+			},
+			statuses: []importStatus{statusSkipped, statusNew, statusNew},
+		},
+		{path: "notes.txt", skipped: "unknown format"},
+	}
+	var buf bytes.Buffer
+	if err := printPreview(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	const want = `march.qif: qif into checking, 2026/03/01 to 2026/03/05
+  duplicate  2026/03/02       -4.50      COFFEE
+  new        2026/03/01     2500.00      PAYROLL
+  new        2026/03/05      -12.50 EUR  CAFE
+  2 new, 1 duplicate, 0 possible duplicate
+  to add: in 2500.00, out 0.00, net 2500.00
+  to add: in 0.00, out -12.50, net -12.50 EUR
+
+notes.txt: skipped: unknown format
+
+dry run: 2 new, 1 duplicate, 0 possible duplicate; nothing was written
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "fin.db")
+	disk, err := openDBAt(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addBatch(t, disk, "earlier", &qif.Entry{Date: date(2026, 3, 2), Amount: -450, Payee: "COFFEE"})
+	disk.Close()
+	before, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "checking.qif")
+	if err := os.WriteFile(path, []byte(watchQIF), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout = stdout }()
+
+	db, err := openDBCopy(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if got := count(t, db, "entry"); got != 1 {
+		t.Errorf("copy has %d entries, want 1", got)
+	}
+	if err := importPath(db, path, "checking", &importOptions{dryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, db, "entry"); got != 1 {
+		t.Errorf("dry run left %d entries, want 1", got)
+	}
+	after, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("dry run changed the database on disk")
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("dry run left files %v", files)
+	}
+
+	// Without a database, a dry run starts from an empty one.
+	missing := filepath.Join(dir, "missing.db")
+	empty, err := openDBCopy(missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty.Close()
+	if _, err := os.Stat(missing); err == nil {
+		t.Errorf("dry run created %s", missing)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileState is what a watcher remembers about a file, to tell when it
+// changes.
+type fileState struct {
+	size  int64
+	mtime int64
+}
+
+// watcher imports the statements that appear in a directory, such as
+// a browser's downloads folder, and moves them to an archive
+// subdirectory once imported.
+type watcher struct {
+	db   *sql.DB
+	dir  string
+	opts *importOptions
+
+	// archive is the directory imported files are moved to.
+	archive string
+	// name is the source for files that the directory's sources file
+	// doesn't match, if any.
+	name string
+
+	// seen holds the state of each file at the last poll.  A file is
+	// only imported once its state stops changing, so that downloads
+	// in progress are left alone.
+	seen map[string]fileState
+	// failed holds the state of files that failed to import, so they
+	// aren't retried until they change.
+	failed map[string]fileState
+	// rules is the state of the sources file when failed was last
+	// cleared.  Editing the file retries the files that failed.
+	rules fileState
+}
+
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{size: info.Size(), mtime: info.ModTime().UnixNano()}
+}
+
+func newWatcher(db *sql.DB, dir, archive, name string, opts *importOptions) *watcher {
+	if !filepath.IsAbs(archive) {
+		archive = filepath.Join(dir, archive)
+	}
+	return &watcher{
+		db:      db,
+		dir:     dir,
+		opts:    opts,
+		archive: archive,
+		name:    name,
+		seen:    map[string]fileState{},
+		failed:  map[string]fileState{},
+	}
+}
+
+// partialDownload reports whether a file name is that of a download
+// still in progress in a browser.
+func partialDownload(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".crdownload", ".part", ".partial", ".download", ".tmp":
+		return true
+	}
+	return false
+}
+
+// run polls the directory forever.
+func (w *watcher) run(interval time.Duration) {
+	log.Printf("watching %s, archiving to %s", w.dir, w.archive)
+	for {
+		if err := w.poll(); err != nil {
+			log.Print(err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// poll imports the files that have stopped changing since the last
+// poll.  Failures are logged rather than returned, so that one bad
+// file doesn't hold up the others.
+func (w *watcher) poll() error {
+	files, err := os.ReadDir(w.dir)
+	if err != nil {
+		return err
+	}
+	// The sources file is reread each time, so it can be edited to
+	// fix a file that had no source.
+	rulesPath := filepath.Join(w.dir, sourcesFile)
+	if state := statFile(rulesPath); state != w.rules {
+		w.rules = state
+		clear(w.failed)
+	}
+	rules, err := loadSourceRules(os.DirFS(w.dir), ".", rulesPath)
+	if err != nil {
+		return err
+	}
+
+	present := map[string]bool{}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || name == sourcesFile || strings.HasPrefix(name, ".") || partialDownload(name) {
+			continue
+		}
+		present[name] = true
+		state := statFile(filepath.Join(w.dir, name))
+		if prev, ok := w.seen[name]; !ok || prev != state {
+			w.seen[name] = state
+			continue
+		}
+		if prev, ok := w.failed[name]; ok && prev == state {
+			continue
+		}
+
+		if err := w.importFile(name, rules); err != nil {
+			log.Print(err)
+			w.failed[name] = state
+			continue
+		}
+		delete(w.failed, name)
+		delete(w.seen, name)
+	}
+	for name := range w.seen {
+		if !present[name] {
+			delete(w.seen, name)
+			delete(w.failed, name)
+		}
+	}
+	return nil
+}
+
+// importFile imports a file in the directory and archives it.  Errors
+// name the file, as import errors do.
+func (w *watcher) importFile(name string, rules []sourceRule) error {
+	path := filepath.Join(w.dir, name)
+	source := w.name
+	if !isZip(name) {
+		// An archive's contents are matched to sources on import.
+		source = matchSource(rules, name, w.name)
+		if source == "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if _, err := inputFormat(path, data, w.opts); err != nil {
+				return err
+			}
+			return fmt.Errorf("%s: no source; add a rule for it to %s", path, sourcesFile)
+		}
+	}
+	if err := importPath(w.db, path, source, w.opts); err != nil {
+		return err
+	}
+	return w.archiveFile(name)
+}
+
+// archiveFile moves a file into the archive directory, numbering it if
+// an earlier file of the same name is already there.
+func (w *watcher) archiveFile(name string) error {
+	if err := os.MkdirAll(w.archive, 0755); err != nil {
+		return err
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	dest := filepath.Join(w.archive, name)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(dest); errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return err
+		}
+		dest = filepath.Join(w.archive, fmt.Sprintf("%s-%d%s", base, i, ext))
+	}
+	path := filepath.Join(w.dir, name)
+	if err := os.Rename(path, dest); err != nil {
+		return err
+	}
+	log.Printf("%s: archived as %s", path, dest)
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const watchQIF = `!Type:Bank
+D03/02/2026
+PCOFFEE
+T-4.50
+^
+`
+
+func writeFile(t *testing.T, path, data string) {
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestWatcherPoll(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	db := testDB(t)
+	dir := t.TempDir()
+	w := newWatcher(db, dir, "imported", "", &importOptions{})
+	poll := func() {
+		if err := w.poll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(t, filepath.Join(dir, "checking.qif"), watchQIF)
+	writeFile(t, filepath.Join(dir, "statement.qif.crdownload"), watchQIF)
+
+	// A new file waits a poll to be sure it's complete.
+	poll()
+	if !exists(filepath.Join(dir, "checking.qif")) {
+		t.Fatalf("imported a file on first sight")
+	}
+
+	// No rule matches it yet, so it fails and isn't retried.
+	poll()
+	if _, ok := w.failed["checking.qif"]; !ok {
+		t.Fatalf("file without a source not marked failed")
+	}
+
+	// Adding a rule retries it.
+	writeFile(t, filepath.Join(dir, sourcesFile), `[{"pattern": "*.qif", "source": "checking"}]`)
+	poll()
+	if exists(filepath.Join(dir, "checking.qif")) {
+		t.Fatalf("file not imported after adding a rule")
+	}
+	if !exists(filepath.Join(dir, "imported", "checking.qif")) {
+		t.Errorf("file not archived")
+	}
+	if !exists(filepath.Join(dir, "statement.qif.crdownload")) {
+		t.Errorf("partial download was touched")
+	}
+
+	var n int
+	if err := db.QueryRow(`select count(*) from entry where source = 'checking'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("got %d entries, want 1", n)
+	}
+
+	// The same file again is archived under another name.
+	writeFile(t, filepath.Join(dir, "checking.qif"), watchQIF)
+	poll()
+	poll()
+	if !exists(filepath.Join(dir, "imported", "checking-1.qif")) {
+		t.Errorf("second file not archived as checking-1.qif")
+	}
+}
+
+func TestWatcherZip(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	db := testDB(t)
+	dir := t.TempDir()
+	w := newWatcher(db, dir, "imported", "", &importOptions{})
+	poll := func() {
+		if err := w.poll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zipPath := filepath.Join(dir, "statements.zip")
+	if err := os.WriteFile(zipPath, zipOf(t, map[string]string{"checking.qif": watchQIF}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	poll()
+	poll()
+	// Nothing in the archive has a source, so it stays to be retried.
+	if !exists(zipPath) {
+		t.Fatalf("archive with no imported files was archived")
+	}
+	if _, ok := w.failed["statements.zip"]; !ok {
+		t.Errorf("archive with no imported files not marked failed")
+	}
+
+	writeFile(t, filepath.Join(dir, sourcesFile), `[{"pattern": "*.qif", "source": "checking"}]`)
+	poll()
+	if exists(zipPath) {
+		t.Fatalf("archive not imported after adding a rule")
+	}
+	if !exists(filepath.Join(dir, "imported", "statements.zip")) {
+		t.Errorf("archive not archived")
+	}
+}
@@ -20,17 +20,34 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 )
 
 type web struct {
 	db *sql.DB
+
+	// currency is the default reporting currency; see toJson.
+	currency string
 }
 
-func (web *web) toJson(w io.Writer) error {
+// toJson writes all entries as JSON.  If currency is non-empty, it's
+// written as the reporting currency, and amounts are converted to it
+// using the exchange rate as of each entry's date, with the amount as
+// imported kept in origAmount and origCurrency.  Entries that can't be
+// converted for lack of a rate keep their original amount and are
+// marked with noRate.
+func (web *web) toJson(w io.Writer, currency string) error {
 	entries, err := allEntries(web.db)
 	if err != nil {
 		return err
 	}
+	var rs rates
+	if currency != "" {
+		rs, err = loadRates(web.db)
+		if err != nil {
+			return err
+		}
+	}
 
 	jentries := []map[string]interface{}{}
 	for _, e := range entries {
@@ -39,19 +56,69 @@ func (web *web) toJson(w io.Writer) error {
 		je["date"] = e.Date
 		je["amount"] = e.Amount
 		je["payee"] = e.Payee
+		if e.Currency != "" {
+			je["currency"] = e.Currency
+		}
+		convert := func(amount int) int { return amount }
+		if currency != "" && e.Currency != "" && e.Currency != currency {
+			date := e.Date
+			if amount, ok := rs.convert(e.Amount, e.Currency, currency, date); ok {
+				je["amount"] = amount
+				je["currency"] = currency
+				je["origAmount"] = e.Amount
+				je["origCurrency"] = e.Currency
+				convert = func(amount int) int {
+					amount, _ = rs.convert(amount, e.Currency, currency, date)
+					return amount
+				}
+			} else {
+				je["noRate"] = true
+			}
+		}
+		if e.Number != "" {
+			je["number"] = e.Number
+		}
+		if e.Address != "" {
+			je["addr"] = e.Address
+		}
+		if e.Memo != "" {
+			je["memo"] = e.Memo
+		}
+		if e.Category != "" {
+			je["category"] = e.Category
+		}
 		je["tags"] = e.Tags
+		if len(e.Splits) > 0 {
+			jsplits := []map[string]interface{}{}
+			for _, sp := range e.Splits {
+				js := make(map[string]interface{})
+				js["id"] = sp.ID
+				js["category"] = sp.Category
+				js["memo"] = sp.Memo
+				js["amount"] = convert(sp.Amount)
+				js["tags"] = sp.Tags
+				jsplits = append(jsplits, js)
+			}
+			je["splits"] = jsplits
+		}
 		jentries = append(jentries, je)
 	}
 	data := map[string]interface{}{
 		"entries": jentries,
 	}
+	if currency != "" {
+		data["currency"] = currency
+	}
 	return json.NewEncoder(w).Encode(data)
 }
 
 func (web *web) updateTagsFromPost(r io.Reader) error {
+	// Ids are entry ids and SplitIds are split ids; the tags apply to
+	// all of them.
 	type tagUpdate struct {
-		Tags []string `json:"tags"`
-		Ids  []int    `json:"ids"`
+		Tags     []string `json:"tags"`
+		Ids      []int    `json:"ids"`
+		SplitIds []int    `json:"splitIds"`
 	}
 
 	var data tagUpdate
@@ -80,6 +147,22 @@ func (web *web) updateTagsFromPost(r io.Reader) error {
 		}
 	}
 
+	for _, id := range data.SplitIds {
+		for _, tag := range data.Tags {
+			if tag[0] == '-' {
+				_, err := tx.Exec(`delete from splittag where splitid = ? and tag = ?`, id, tag[1:])
+				if err != nil {
+					return err
+				}
+			} else {
+				_, err := tx.Exec(`insert or ignore into splittag (splitid, tag) values (?, ?)`, id, tag)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	return tx.Commit()
 }
 
@@ -100,9 +183,13 @@ func (web *web) start(addr string) {
 
 		fs.ServeHTTP(w, r)
 	})
-	http.HandleFunc("/data", func(w http.ResponseWriter, _ *http.Request) {
+	http.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		currency := web.currency
+		if c := r.URL.Query().Get("currency"); c != "" {
+			currency = strings.ToUpper(c)
+		}
 		w.Header().Add("Content-Type", "application/json")
-		if err := web.toJson(w); err != nil {
+		if err := web.toJson(w, currency); err != nil {
 			log.Print(err)
 		}
 	})
@@ -0,0 +1,68 @@
+// Copyright 2026 Evan Martin. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evmar/fin/bank/qif"
+)
+
+func TestToJsonCurrency(t *testing.T) {
+	db := testDB(t)
+	addBatch(t, db, "statement",
+		&qif.Entry{Date: date(2024, 2, 1), Amount: -1000, Currency: "EUR", Payee: "CAFE"},
+		&qif.Entry{Date: date(2024, 2, 2), Amount: -2000, Currency: "GBP", Payee: "PUB"},
+		&qif.Entry{Date: date(2024, 2, 3), Amount: -3000, Currency: "USD", Payee: "DINER"},
+	)
+	if _, err := db.Exec(`insert into rate (date, currency, base, rate) values ('2024/01/31', 'EUR', 'USD', 1.1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := (&web{db: db}).toJson(&buf, "USD"); err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Currency string
+		Entries  []struct {
+			Payee, Currency, OrigCurrency string
+			Amount, OrigAmount            int
+			NoRate                        bool
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Currency != "USD" {
+		t.Errorf("currency: got %q, want USD", data.Currency)
+	}
+	got := map[string]string{}
+	for _, e := range data.Entries {
+		got[e.Payee] = fmt.Sprintf("%d %s %d %s %v", e.Amount, e.Currency, e.OrigAmount, e.OrigCurrency, e.NoRate)
+	}
+	want := map[string]string{
+		"CAFE":  "-1100 USD -1000 EUR false",
+		"PUB":   "-2000 GBP 0  true",
+		"DINER": "-3000 USD 0  false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
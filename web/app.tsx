@@ -16,6 +16,7 @@ import * as d3 from 'd3';
 import * as preact from 'preact';
 import * as ledger from './ledger';
 import { OverviewPage } from './overview';
+import { setNotice } from './page';
 import { TaggerPage, UntaggedPage } from './tagger';
 import { Entry } from './types';
 import * as util from './util';
@@ -64,6 +65,8 @@ export function reload() {
 /** As returned from `/data` endpoint. */
 interface DataJSON {
   entries: Entry[];
+  /** The currency amounts were converted to, if any. */
+  currency?: string;
 }
 
 namespace App {
@@ -130,10 +133,22 @@ class AppShell extends preact.Component<{}> {
   }
 
   async load() {
-    const data: DataJSON = await (await fetch('/data')).json();
+    // ?currency=EUR reports all amounts converted to that currency.
+    const currency = new URLSearchParams(document.location.search).get('currency');
+    const url = currency ? `/data?currency=${encodeURIComponent(currency)}` : '/data';
+    const data: DataJSON = await (await fetch(url)).json();
 
     let entries = data.entries;
     entries = entries.filter((e) => e.amount != 0);
+    // Entries without a rate are still in their own currency, so they
+    // are left out of every view rather than mixed into the totals.
+    const noRate = entries.filter((e) => e.noRate).length;
+    entries = entries.filter((e) => !e.noRate);
+    setNotice(
+      noRate > 0
+        ? `${noRate} entries have no exchange rate to ${data.currency} and are left out.`
+        : undefined,
+    );
     entries = entries.sort((a, b) => d3.descending(a.date, b.date));
 
     (window as any).data = data;
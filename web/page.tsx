@@ -14,6 +14,13 @@
 
 import * as preact from 'preact';
 
+let notice: string | undefined;
+
+/** Sets a message shown on every page, e.g. about entries left out. */
+export function setNotice(text: string | undefined) {
+  notice = text;
+}
+
 interface Props {
   extraHead?: preact.VNode;
 }
@@ -25,6 +32,7 @@ export class Page extends preact.Component<Props, {}> {
         <header>
           <h1>fin</h1>
           {this.props.extraHead}
+          {notice && <p class='notice'>{notice}</p>}
         </header>
         <main>{this.props.children}</main>
       </>
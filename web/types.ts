@@ -16,8 +16,27 @@ export interface Entry {
   id: number;
   addr?: string;
   amount: number;
+  /** Currency of amount, if known. */
+  currency?: string;
+  /** The amount as imported, when amount was converted to another currency. */
+  origAmount?: number;
+  origCurrency?: string;
+  /** Set when amount couldn't be converted for lack of an exchange rate. */
+  noRate?: boolean;
   date: string;
   number?: string;
+  memo?: string;
+  category?: string;
   payee: string;
   tags?: string[];
+  splits?: Split[];
+}
+
+/** One part of a split transaction, tagged separately from its entry. */
+export interface Split {
+  id: number;
+  category: string;
+  memo: string;
+  amount: number;
+  tags?: string[];
 }